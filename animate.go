@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultAnimateDelay is the pause after each character -animate types
+const defaultAnimateDelay = 15 * time.Millisecond
+
+// fadeFrame is how long each shade of the translation's fade-in is shown
+const fadeFrame = 80 * time.Millisecond
+
+// fadeShades are the grays of the 256-color palette the translation goes
+// through before it takes its own style
+var fadeShades = []int{236, 238, 240, 243, 246, 249, 252}
+
+// markers around the translation in the output of an -animate renderer,
+// whose lines are faded in rather than typed. Both are invisible format
+// characters so they take no room when the verse is laid out.
+const (
+	fadeStart = "\u2061"
+	fadeEnd   = "\u2062"
+)
+
+// fadeIn marks text to be faded in when r animates
+func (r renderer) fadeIn(text string) string {
+	if !r.animate {
+		return text
+	}
+	return fadeStart + text + fadeEnd
+}
+
+// animator writes text as if typed, pausing after each character, and
+// fades in the marked lines. An interrupt writes the rest at once.
+type animator struct {
+	w         io.Writer
+	delay     time.Duration
+	fade      bool // the terminal takes colors and cursor moves, else marked lines are typed too
+	interrupt chan os.Signal
+	done      bool // interrupted: no more pauses
+}
+
+// animate writes text to w with the typewriter effect of -animate,
+// catching Ctrl-C meanwhile to show the rest of the text at once
+func animate(w io.Writer, text string, delay time.Duration, fade bool) error {
+	a := &animator{w: w, delay: delay, fade: fade, interrupt: make(chan os.Signal, 1)}
+	signal.Notify(a.interrupt, os.Interrupt)
+	defer signal.Stop(a.interrupt)
+	for text != "" {
+		start := strings.Index(text, fadeStart)
+		if start < 0 {
+			return a.typeOut(text)
+		}
+		// fade whole lines, from the one the marker starts to the one it ends
+		lineStart := strings.LastIndex(text[:start], "\n") + 1
+		if err := a.typeOut(text[:lineStart]); err != nil {
+			return err
+		}
+		end := len(text)
+		if i := strings.Index(text[start:], fadeEnd); i >= 0 {
+			end = start + i
+		}
+		if i := strings.IndexByte(text[end:], '\n'); i >= 0 {
+			end += i + 1
+		} else {
+			end = len(text)
+		}
+		block := strings.NewReplacer(fadeStart, "", fadeEnd, "").Replace(text[lineStart:end])
+		if err := a.fadeLines(block); err != nil {
+			return err
+		}
+		text = text[end:]
+	}
+	return nil
+}
+
+// pause waits for the delay unless interrupted, reporting whether it did
+func (a *animator) pause(d time.Duration) bool {
+	if a.done {
+		return false
+	}
+	select {
+	case <-a.interrupt:
+		a.done = true
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// typeOut writes text a character at a time: escape sequences at once, and
+// each letter with the marks combining with it, as Devanagari vowel signs do
+func (a *animator) typeOut(text string) error {
+	if a.done || a.delay <= 0 {
+		_, err := io.WriteString(a.w, text)
+		return err
+	}
+	for i := 0; i < len(text); {
+		n := 1
+		if loc := ansiEscape.FindStringIndex(text[i:]); loc != nil && loc[0] == 0 {
+			n = loc[1]
+		} else {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			n = size
+			for i+n < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i+n:])
+				if !unicode.Is(unicode.M, r) {
+					break
+				}
+				n += size
+			}
+		}
+		if _, err := io.WriteString(a.w, text[i:i+n]); err != nil {
+			return err
+		}
+		if text[i] != '\033' && !a.pause(a.delay) {
+			_, err := io.WriteString(a.w, text[i+n:])
+			return err
+		}
+		i += n
+	}
+	return nil
+}
+
+// fadeLines writes the lines of block in ever lighter grays, each shade over
+// the last, and then as they are styled
+func (a *animator) fadeLines(block string) error {
+	if !a.fade || a.done || !strings.HasSuffix(block, "\n") {
+		return a.typeOut(block)
+	}
+	lines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+	var frame strings.Builder
+	for _, shade := range fadeShades {
+		frame.Reset()
+		for _, line := range lines {
+			fmt.Fprintf(&frame, "\033[2K\033[38;5;%dm%s%s\n", shade, ansiEscape.ReplaceAllString(line, ""), Reset)
+		}
+		fmt.Fprintf(&frame, "\033[%dA", len(lines))
+		if _, err := io.WriteString(a.w, frame.String()); err != nil {
+			return err
+		}
+		if !a.pause(fadeFrame) {
+			break
+		}
+	}
+	frame.Reset()
+	for _, line := range lines {
+		fmt.Fprintf(&frame, "\033[2K%s\n", line)
+	}
+	_, err := io.WriteString(a.w, frame.String())
+	return err
+}
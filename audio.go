@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// audioTimeout bounds the download of one recitation
+const audioTimeout = 60 * time.Second
+
+// audioPlayers lists the command-line players to try on each platform, in
+// order of preference; the file to play is appended
+var audioPlayers = map[string][][]string{
+	"darwin":  {{"afplay"}, {"mpv", "--no-video", "--really-quiet"}, {"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"}},
+	"windows": {{"mpv", "--no-video", "--really-quiet"}, {"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"}},
+	"linux": {{"mpv", "--no-video", "--really-quiet"}, {"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+		{"mpg123", "-q"}, {"cvlc", "--play-and-exit", "--quiet"}},
+}
+
+// errNoPlayer is returned when no audio player is installed
+var errNoPlayer = errors.New("no audio player found")
+
+// audioURL fills the {chapter} and {verse} placeholders of template
+func audioURL(template string, s Sloka) (string, error) {
+	if !strings.Contains(template, "{chapter}") || !strings.Contains(template, "{verse}") {
+		return "", fmt.Errorf("audio URL %q needs {chapter} and {verse} placeholders", template)
+	}
+	raw := strings.NewReplacer("{chapter}", strconv.Itoa(s.Chapter), "{verse}", strconv.Itoa(s.Verse)).Replace(template)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid audio URL %q", raw)
+	}
+	return raw, nil
+}
+
+// fetchAudio returns the cached recitation of s, downloading it from the
+// template URL the first time
+func fetchAudio(cache *httpCache, template string, s Sloka) (string, error) {
+	rawURL, err := audioURL(template, s)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), audioTimeout)
+	defer cancel()
+	file, resp, err := cache.get(ctx, rawURL)
+	if resp != nil {
+		resp.Body.Close()
+		return "", fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+	return file, err
+}
+
+// playAudio plays file with the first available player for the platform
+func playAudio(file string) error {
+	for _, args := range audioPlayers[runtime.GOOS] {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], append(args[1:], file)...)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	return errNoPlayer
+}
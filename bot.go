@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chat platforms accepted by -bot-platform
+const (
+	BotSlack    = "slack"
+	BotDiscord  = "discord"
+	BotTelegram = "telegram"
+)
+
+// botPlatforms lists the -bot-platform values
+var botPlatforms = []string{BotSlack, BotDiscord, BotTelegram}
+
+const (
+	botTimeout  = 10 * time.Second // for each webhook request
+	maxBotDelay = 30 * time.Second // longest Retry-After honored between attempts
+	embedColor  = 0xE07A1F         // saffron bar of the Discord embed
+)
+
+// detectPlatform returns the platform of a webhook URL from its host, or ""
+func detectPlatform(webhook string) string {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return ""
+	}
+	switch host := strings.ToLower(u.Hostname()); {
+	case host == "hooks.slack.com":
+		return BotSlack
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return BotDiscord
+	case host == "api.telegram.org":
+		return BotTelegram
+	}
+	return ""
+}
+
+// botVerse is the text of a verse as posted to a chat
+type botVerse struct {
+	title, sanskrit, transliteration, translation, author string
+}
+
+// botText returns the parts of s posted by the bot
+func (r renderer) botText(s Sloka) botVerse {
+	r = r.resolve(s)
+	text, author := r.translation(s)
+	var sanskrit []string
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sanskrit = append(sanskrit, line)
+		}
+	}
+	return botVerse{
+		title:           fmt.Sprintf("%s %d.%d", r.text.Title, s.Chapter, s.Verse),
+		sanskrit:        strings.Join(sanskrit, "\n"),
+		transliteration: strings.Join(transliterationLines(r.transliteration(s)), "\n"),
+		translation:     strings.Join(strings.Fields(cleanTranslation(text)), " "),
+		author:          author,
+	}
+}
+
+// escapers for the markup of each platform
+var (
+	slackEscaper    = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	discordEscaper  = regexp.MustCompile("([\\\\*_~`|>])")
+	telegramEscaper = regexp.MustCompile("([\\\\_*\\[\\]()~`>#+\\-=|{}.!])")
+)
+
+// botPayload returns the JSON body posting s: Slack blocks, a Discord embed
+// or a Telegram message in MarkdownV2 for chat, the chat id
+func (r renderer) botPayload(platform string, s Sloka, chat string) ([]byte, error) {
+	v := r.botText(s)
+	var payload any
+	switch platform {
+	case BotSlack:
+		esc := slackEscaper.Replace
+		body := fmt.Sprintf("%s\n\n%s\n\n>%s", esc(v.sanskrit), eachLine("_", esc(v.transliteration)), esc(v.translation))
+		payload = map[string]any{
+			"text": v.title + ": " + v.translation,
+			"blocks": []any{
+				map[string]any{"type": "header", "text": map[string]any{"type": "plain_text", "text": v.title}},
+				map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": body}},
+				map[string]any{"type": "context", "elements": []any{map[string]any{"type": "mrkdwn", "text": "— " + esc(v.author)}}},
+			},
+		}
+	case BotDiscord:
+		esc := func(s string) string { return discordEscaper.ReplaceAllString(s, `\$1`) }
+		payload = map[string]any{
+			"embeds": []any{map[string]any{
+				"title":       v.title,
+				"description": fmt.Sprintf("%s\n\n%s\n\n%s", esc(v.sanskrit), eachLine("*", esc(v.transliteration)), esc(v.translation)),
+				"color":       embedColor,
+				"footer":      map[string]any{"text": v.author},
+			}},
+		}
+	case BotTelegram:
+		if chat == "" {
+			return nil, errors.New("telegram needs the chat to post to, set with -bot-chat")
+		}
+		esc := func(s string) string { return telegramEscaper.ReplaceAllString(s, `\$1`) }
+		payload = map[string]any{
+			"chat_id": chat,
+			"text": fmt.Sprintf("*%s*\n\n%s\n\n%s\n\n%s\n— %s", esc(v.title), esc(v.sanskrit),
+				eachLine("_", esc(v.transliteration)), esc(v.translation), esc(v.author)),
+			"parse_mode": "MarkdownV2",
+		}
+	default:
+		return nil, fmt.Errorf("unknown platform %q", platform)
+	}
+
+	// keep > and & as they are, the markup of Slack's quotes and escapes
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(payload)
+	return bytes.TrimSpace(b.Bytes()), err
+}
+
+// eachLine wraps every line of text in mark, since emphasis cannot span
+// lines in chat markup
+func eachLine(mark, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = mark + line + mark
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postWebhook posts body to webhook, retrying up to retries times with a
+// growing delay when the request fails, the server errs or rate limits
+func postWebhook(webhook string, body []byte, retries int) error {
+	client := &http.Client{Timeout: botTimeout}
+	var err error
+	delay := time.Second
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		var resp *http.Response
+		resp, err = client.Post(webhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// the error names the URL, and with it the token
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			continue
+		}
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests:
+			if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+				delay = min(time.Duration(seconds)*time.Second, maxBotDelay)
+			}
+		case resp.StatusCode < 500:
+			return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(reply)))
+		}
+		err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return err
+}
+
+// redactURL hides the path of a webhook URL for printing, since it holds
+// the token on every platform
+func redactURL(webhook string) string {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Host == "" {
+		return "(no URL)"
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+	"golang.org/x/term"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// browseHelp lists the keys of the browser
+const browseHelp = "[n] next  [p] previous  [r] random  [t] translation  [g] go to  [/] search  [q] quit"
+
+// browse shows one verse at a time, starting at start, and moves through the
+// book in chapter and verse order on single keypresses: n or → for the next
+// verse, p or ← for the previous one and r for a random one. t switches to
+// the next translation source, g jumps to a CHAPTER.VERSE reference, / finds
+// the next verse whose translation contains a term, and q quits. Moving past
+// either end wraps around.
+func browse(book gita.Scripture, start Sloka, rng *rand.Rand, opts Options) error {
+	ordered := inOrder(book.Data().Slokas)
+	current := 0
+	for i, s := range ordered {
+		if s.ID == start.ID {
+			current = i
+			break
+		}
+	}
+
+	status := ""
+	for {
+		var out strings.Builder
+		render(&out, book, ordered[current:current+1], opts)
+		fmt.Print(clearScreen + out.String())
+		if status != "" {
+			fmt.Println(status)
+		}
+		fmt.Printf("%s%s%s\n", Dim, browseHelp, Reset)
+		status = ""
+
+		key, err := readKey()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "n", "\033[C":
+			current = (current + 1) % len(ordered)
+		case "p", "\033[D":
+			current = (current - 1 + len(ordered)) % len(ordered)
+		case "r":
+			current = rng.Intn(len(ordered))
+		case "t":
+			opts.source = nextSource(opts.source)
+			status = fmt.Sprintf("Translation: %s", opts.source)
+		case "g":
+			ref, err := prompt("Go to (CHAPTER.VERSE): ")
+			if err != nil {
+				return err
+			}
+			if i, ok := findReference(ordered, ref); ok {
+				current = i
+			} else {
+				status = fmt.Sprintf("No verse %q.", ref)
+			}
+		case "/":
+			term, err := prompt("Search: ")
+			if err != nil {
+				return err
+			}
+			if i, ok := findNext(ordered, current, term, opts.renderer); ok {
+				current = i
+			} else if term != "" {
+				status = fmt.Sprintf("No verses found matching %q.", term)
+			}
+		case "q", "\003", "\004":
+			// q, Ctrl-C or Ctrl-D
+			return nil
+		}
+	}
+}
+
+// nextSource returns the translation source after source, in -list-sources
+// order
+func nextSource(source string) string {
+	for i, t := range translators {
+		if t.Key == source {
+			return translators[(i+1)%len(translators)].Key
+		}
+	}
+	return translators[0].Key
+}
+
+// findReference returns the index in ordered of the verse "CHAPTER.VERSE",
+// or of the first verse of the chapter for "CHAPTER"
+func findReference(ordered []Sloka, ref string) (int, bool) {
+	chapterPart, versePart, hasVerse := strings.Cut(strings.TrimSpace(ref), ".")
+	chapter, err := strconv.Atoi(chapterPart)
+	if err != nil {
+		return 0, false
+	}
+	verse := 1
+	if hasVerse {
+		if verse, err = strconv.Atoi(versePart); err != nil {
+			return 0, false
+		}
+	}
+	for i, s := range ordered {
+		if s.Chapter == chapter && s.Verse == verse {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// findNext returns the index of the first verse after current, wrapping
+// around, whose active translation contains term, ignoring case
+func findNext(ordered []Sloka, current int, term string, r renderer) (int, bool) {
+	if term == "" {
+		return 0, false
+	}
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+	for step := 1; step <= len(ordered); step++ {
+		i := (current + step) % len(ordered)
+		if len(searchSlokas(ordered[i:i+1], r, pattern, []string{FieldTranslation})) > 0 {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// readKey reads a single keypress from stdin, which must be a terminal. The
+// terminal is only in raw mode while waiting, so output renders normally.
+func readKey() (string, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	// arrow keys arrive as a short escape sequence in one read
+	buf := make([]byte, 8)
+	n, err := os.Stdin.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+// prompt prints label and reads a line from stdin with the terminal in its
+// normal mode, so the input is echoed and can be edited
+func prompt(label string) (string, error) {
+	fmt.Print(label)
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		if n == 0 || buf[0] == '\n' {
+			return strings.TrimSpace(string(line)), nil
+		}
+		line = append(line, buf[0])
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultCacheTTL is how long a cached response is used before it is
+// fetched again
+const defaultCacheTTL = 30 * 24 * time.Hour
+
+// cacheDir returns the gitasay cache directory, honoring XDG_CACHE_HOME
+func cacheDir() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, "gitasay"), nil
+}
+
+// httpCache keeps the bodies of fetched URLs on disk, content-addressed:
+// each body is stored once in objects/ under its sha256, and each URL has an
+// entry in index/, under the sha256 of the URL, naming the body it last
+// returned. Entries younger than ttl are used without a request; older ones
+// are revalidated, and used anyway when the server cannot be reached.
+type httpCache struct {
+	dir      string
+	ttl      time.Duration
+	warnings io.Writer // told when a stale entry stands in for a failed request
+}
+
+// cacheEntry is what the index keeps for a URL
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	Object       string    `json:"object"` // sha256 of the body, with the extension of the URL
+	Fetched      time.Time `json:"fetched"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+// newHTTPCache returns the cache in the gitasay cache directory
+func newHTTPCache(ttl time.Duration) (*httpCache, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &httpCache{dir: dir, ttl: ttl, warnings: os.Stderr}, nil
+}
+
+// hashHex returns the hex sha256 of data
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// indexPath returns the file of rawURL's entry
+func (c *httpCache) indexPath(rawURL string) string {
+	return filepath.Join(c.dir, "index", hashHex([]byte(rawURL))+".json")
+}
+
+// objectPath returns the file of an entry's body
+func (c *httpCache) objectPath(e cacheEntry) string {
+	return filepath.Join(c.dir, "objects", e.Object)
+}
+
+// lookup returns the entry of rawURL, if it has one whose body is stored
+func (c *httpCache) lookup(rawURL string) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.indexPath(rawURL))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if json.Unmarshal(data, &e) != nil || e.URL != rawURL || e.Object == "" {
+		return cacheEntry{}, false
+	}
+	if _, err := os.Stat(c.objectPath(e)); err != nil {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// save writes the entry of e.URL
+func (c *httpCache) save(e cacheEntry) error {
+	return writeState(c.indexPath(e.URL), e)
+}
+
+// store writes body to the objects, once whatever the URLs returning it,
+// and returns its name, keeping the extension of rawURL so that players
+// recognize the format of recitations
+func (c *httpCache) store(rawURL string, body []byte) (string, error) {
+	name := hashHex(body)
+	if u, err := url.Parse(rawURL); err == nil {
+		name += strings.ToLower(path.Ext(u.Path))
+	}
+	file := filepath.Join(c.dir, "objects", name)
+	if _, err := os.Stat(file); err == nil {
+		return name, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	// write next to the object so a failed write leaves nothing
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return name, os.Rename(tmp.Name(), file)
+}
+
+// get returns the file holding the body of rawURL, fetching it when the
+// cache has no fresh copy. When the server refuses the request with a
+// client error, the response is returned instead, for the caller to read
+// and close.
+func (c *httpCache) get(ctx context.Context, rawURL string) (string, *http.Response, error) {
+	cached, ok := c.lookup(rawURL)
+	if ok && time.Since(cached.Fetched) < c.ttl {
+		return c.objectPath(cached), nil, nil
+	}
+	stale := func(err error) (string, *http.Response, error) {
+		if !ok {
+			return "", nil, err
+		}
+		fmt.Fprintf(c.warnings, "Warning: %v; using the copy cached on %s\n", err, cached.Fetched.Local().Format("2006-01-02"))
+		return c.objectPath(cached), nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return stale(err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		resp.Body.Close()
+		cached.Fetched = time.Now()
+		return c.objectPath(cached), nil, c.save(cached)
+	case resp.StatusCode >= 500:
+		resp.Body.Close()
+		return stale(fmt.Errorf("%s: %s", rawURL, resp.Status))
+	case resp.StatusCode != http.StatusOK:
+		return "", resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return stale(err)
+	}
+	e := cacheEntry{URL: rawURL, Fetched: time.Now(), ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	if e.Object, err = c.store(rawURL, body); err != nil {
+		return "", nil, err
+	}
+	return c.objectPath(e), nil, c.save(e)
+}
+
+// RoundTrip implements http.RoundTripper, answering GET requests from the
+// cache so a client can go through it
+func (c *httpCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	file, resp, err := c.get(req.Context(), req.URL.String())
+	if resp != nil || err != nil {
+		return resp, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          f,
+		ContentLength: info.Size(),
+		Request:       req,
+	}, nil
+}
+
+// CacheStatusJSON describes the cache for -cache-status
+type CacheStatusJSON struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"` // URLs cached
+	Stale   int    `json:"stale"`   // entries older than the TTL
+	Files   int    `json:"files"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// status counts the entries and files of the cache
+func (c *httpCache) status() (CacheStatusJSON, error) {
+	st := CacheStatusJSON{Dir: c.dir}
+	err := filepath.WalkDir(c.dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		st.Files++
+		st.Bytes += info.Size()
+		if filepath.Base(filepath.Dir(p)) != "index" {
+			return nil
+		}
+		var e cacheEntry
+		if data, err := os.ReadFile(p); err == nil && json.Unmarshal(data, &e) == nil {
+			st.Entries++
+			if time.Since(e.Fetched) >= c.ttl {
+				st.Stale++
+			}
+		}
+		return nil
+	})
+	return st, err
+}
+
+// clear removes the cache, returning what it held
+func (c *httpCache) clear() (CacheStatusJSON, error) {
+	st, err := c.status()
+	if err != nil {
+		return st, err
+	}
+	return st, os.RemoveAll(c.dir)
+}
+
+// byteSize formats n bytes for people, e.g. "4.2 MB"
+func byteSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// printCacheStatus writes where the cache is and what it holds
+func printCacheStatus(w io.Writer, st CacheStatusJSON) {
+	fmt.Fprintf(w, "Cache: %s\n", st.Dir)
+	fmt.Fprintf(w, "Entries: %d (%d stale)\n", st.Entries, st.Stale)
+	fmt.Fprintf(w, "Size: %s in %d files\n", byteSize(st.Bytes), st.Files)
+}
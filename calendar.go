@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// a small lunisolar calendar: enough of the Hindu panchang to date the
+// occasions, from low-precision positions of the sun and moon (Meeus,
+// Astronomical Algorithms, chapters 25 and 47) good to a few minutes of a
+// tithi's start
+
+const (
+	synodicMonth = 29.530588853 // mean days from new moon to new moon
+	tithiDegrees = 12           // elongation of the moon from the sun per tithi
+)
+
+// lunar months, amanta: each runs from a new moon to the next and is named
+// after the zodiac sign the sun enters during it
+var lunarMonths = []string{
+	"Chaitra", "Vaishakha", "Jyeshtha", "Ashadha", "Shravana", "Bhadrapada",
+	"Ashvin", "Kartika", "Margashirsha", "Pausha", "Magha", "Phalguna",
+}
+
+// the lunar months by name
+const (
+	Chaitra = iota
+	Vaishakha
+	Jyeshtha
+	Ashadha
+	Shravana
+	Bhadrapada
+	Ashvin
+	Kartika
+	Margashirsha
+	Pausha
+	Magha
+	Phalguna
+)
+
+// julianDay returns the Julian day of t
+func julianDay(t time.Time) float64 {
+	return float64(t.UTC().UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+// normDegrees returns d in [0, 360)
+func normDegrees(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+// sinDeg is the sine of d degrees
+func sinDeg(d float64) float64 {
+	return math.Sin(d * math.Pi / 180)
+}
+
+// sunLongitude returns the apparent tropical longitude of the sun at t
+func sunLongitude(t time.Time) float64 {
+	T := (julianDay(t) - 2451545) / 36525
+	L0 := 280.46646 + 36000.76983*T
+	M := 357.52911 + 35999.05029*T
+	C := (1.914602-0.004817*T)*sinDeg(M) + (0.019993-0.000101*T)*sinDeg(2*M) + 0.000289*sinDeg(3*M)
+	return normDegrees(L0 + C - 0.00569 - 0.00478*sinDeg(125.04-1934.136*T))
+}
+
+// moonTerms are the largest periodic terms of the moon's longitude: the
+// multiples of D, M, M' and F and the amplitude in millionths of a degree
+var moonTerms = [][5]float64{
+	{0, 0, 1, 0, 6288774}, {2, 0, -1, 0, 1274027}, {2, 0, 0, 0, 658314},
+	{0, 0, 2, 0, 213618}, {0, 1, 0, 0, -185116}, {0, 0, 0, 2, -114332},
+	{2, 0, -2, 0, 58793}, {2, -1, -1, 0, 57066}, {2, 0, 1, 0, 53322},
+	{2, -1, 0, 0, 45758}, {0, 1, -1, 0, -40923}, {1, 0, 0, 0, -34720},
+	{0, 1, 1, 0, -30383}, {2, 0, 0, -2, 15327}, {0, 0, 1, 2, -12528},
+	{0, 0, 1, -2, 10980}, {4, 0, -1, 0, 10675}, {0, 0, 3, 0, 10034},
+	{4, 0, -2, 0, 8548}, {2, 1, -1, 0, -7888}, {2, 1, 0, 0, -6766},
+	{1, 0, -1, 0, -5163}, {1, 1, 0, 0, 4987}, {2, -1, 1, 0, 4036},
+	{2, 0, 2, 0, 3994}, {4, 0, 0, 0, 3861}, {2, 0, -3, 0, 3665},
+	{0, 1, -2, 0, -2689}, {2, 0, -1, 2, -2602}, {2, -1, -2, 0, 2390},
+	{1, 0, 1, 0, -2348}, {2, -2, 0, 0, 2236}, {0, 1, 2, 0, -2120},
+	{0, 2, 0, 0, -2069}, {2, -2, -1, 0, 2048},
+}
+
+// moonLongitude returns the apparent tropical longitude of the moon at t
+func moonLongitude(t time.Time) float64 {
+	T := (julianDay(t) - 2451545) / 36525
+	L := 218.3164477 + 481267.88123421*T
+	D := 297.8501921 + 445267.1114034*T
+	M := 357.5291092 + 35999.0502909*T
+	Mm := 134.9633964 + 477198.8675055*T
+	F := 93.2720950 + 483202.0175233*T
+	E := 1 - 0.002516*T
+	var sum float64
+	for _, term := range moonTerms {
+		amplitude := term[4]
+		// terms with the sun's anomaly shrink with the eccentricity of the earth's orbit
+		for range int(math.Abs(term[1])) {
+			amplitude *= E
+		}
+		sum += amplitude * sinDeg(term[0]*D+term[1]*M+term[2]*Mm+term[3]*F)
+	}
+	return normDegrees(L + sum/1e6 - 0.00478*sinDeg(125.04-1934.136*T))
+}
+
+// elongation returns how far the moon is east of the sun at t, in [0, 360)
+func elongation(t time.Time) float64 {
+	return normDegrees(moonLongitude(t) - sunLongitude(t))
+}
+
+// tithiAt returns the lunar day in force at t, 1 to 15 in the waxing
+// fortnight (15 the full moon) and 16 to 30 in the waning one (30 the new moon)
+func tithiAt(t time.Time) int {
+	return int(elongation(t)/tithiDegrees) + 1
+}
+
+// ayanamsa returns the Lahiri precession at t, the degrees between the
+// tropical and sidereal zodiacs
+func ayanamsa(t time.Time) float64 {
+	return 23.853 + 0.013969*(julianDay(t)-2451545)/365.25
+}
+
+// siderealSign returns the sidereal zodiac sign of the sun at t, 0 for
+// Mesha (Aries) to 11 for Meena (Pisces)
+func siderealSign(t time.Time) int {
+	return int(normDegrees(sunLongitude(t)-ayanamsa(t)) / 30)
+}
+
+// newMoonBefore returns the last new moon at or before t
+func newMoonBefore(t time.Time) time.Time {
+	days := func(d float64) time.Duration { return time.Duration(d * float64(24*time.Hour)) }
+	nm := t.Add(-days(elongation(t) / 360 * synodicMonth))
+	// close in on the conjunction, the elongation falling by about 12
+	// degrees a day
+	for range 5 {
+		e := elongation(nm)
+		if e > 180 {
+			e -= 360
+		}
+		nm = nm.Add(-days(e / 360 * synodicMonth))
+	}
+	if nm.After(t) {
+		return newMoonBefore(nm.Add(-days(1)))
+	}
+	return nm
+}
+
+// lunarMonthAt returns the amanta month in force at t and whether it is
+// an intercalary (adhika) month, one in which the sun enters no new sign
+// and which therefore repeats the name of the month after it
+func lunarMonthAt(t time.Time) (month int, adhika bool) {
+	start := newMoonBefore(t)
+	end := newMoonBefore(start.Add(time.Duration((synodicMonth + 2) * float64(24*time.Hour))))
+	sign := siderealSign(start)
+	return (sign + 1) % 12, siderealSign(end) == sign
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cardWidth and cardPadding are the width of a -image card and its margin,
+// in pixels
+const (
+	cardWidth   = 1200
+	cardPadding = 80
+)
+
+// cardFonts are tried in order by the viewer; the Devanagari faces come
+// first so the Sanskrit is shaped properly
+const cardFonts = "'Noto Serif Devanagari', 'Noto Sans Devanagari', 'Mangal', 'Tiro Devanagari Sanskrit', serif"
+
+// cardLine is one line of text on a card
+type cardLine struct {
+	text  string
+	size  int    // font size in pixels
+	style string // extra SVG attributes, e.g. ` font-style="italic"`
+}
+
+// parseHexColor checks that color is a #rrggbb color
+func parseHexColor(color string) error {
+	if len(color) != 7 || color[0] != '#' {
+		return fmt.Errorf("invalid color %q (expected #rrggbb)", color)
+	}
+	if _, err := strconv.ParseUint(color[1:], 16, 32); err != nil {
+		return fmt.Errorf("invalid color %q (expected #rrggbb)", color)
+	}
+	return nil
+}
+
+// cardLines lays out s to fit width pixels: the Sanskrit, the
+// transliteration, the translation and the attribution, each wrapped at its
+// font size, which scale multiplies
+func (r renderer) cardLines(s Sloka, width int, scale float64) []cardLine {
+	r = r.resolve(s)
+	size := func(px int) int { return int(float64(px) * scale) }
+	// about half an em per character of Latin text; Devanagari runs wider
+	columns := func(size int, perEm float64) int {
+		return int(float64(width) / (float64(size) * perEm))
+	}
+	wrapped := func(text string, size int, perEm float64, style string) []cardLine {
+		var lines []cardLine
+		for _, line := range strings.Split(r.wrapper(text, columns(size, perEm)), "\n") {
+			lines = append(lines, cardLine{line, size, style})
+		}
+		return lines
+	}
+	// blank lines carry the height of the gap
+	gap := cardLine{size: size(20)}
+
+	var lines []cardLine
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, wrapped(line, size(34), 0.6, ` font-weight="bold"`)...)
+		}
+	}
+	lines = append(lines, gap)
+	for _, line := range transliterationLines(r.transliteration(s)) {
+		lines = append(lines, wrapped(line, size(24), 0.5, ` font-style="italic" opacity="0.8"`)...)
+	}
+	lines = append(lines, gap)
+	text, author := r.translation(s)
+	lines = append(lines, wrapped(cleanTranslation(text), size(28), 0.5, "")...)
+	lines = append(lines, gap)
+	attribution := fmt.Sprintf("— %s, %s %d.%d", author, r.text.Title, s.Chapter, s.Verse)
+	lines = append(lines, cardLine{attribution, size(22), ` opacity="0.7"`})
+	return lines
+}
+
+// writeCard writes s as an SVG quote card with the given background and text
+// colors. The text stays text, so any viewer with a Devanagari font renders
+// and shapes it, and the card scales to any size.
+func (r renderer) writeCard(w io.Writer, s Sloka, background, foreground string) error {
+	lines := r.cardLines(s, cardWidth-2*cardPadding, 1)
+	height := 2 * cardPadding
+	for _, line := range lines {
+		height += lineHeight(line)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		cardWidth, height, cardWidth, height)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" rx=\"24\" fill=\"%s\"/>\n", background)
+	fmt.Fprintf(&b, "<g fill=\"%s\" font-family=\"%s\">\n", foreground, cardFonts)
+	y := cardPadding
+	for _, line := range lines {
+		y += lineHeight(line)
+		if line.text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" font-size=\"%d\"%s>%s</text>\n",
+			cardPadding, y, line.size, line.style, html.EscapeString(line.text))
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// lineHeight returns the vertical space of line, its size for blank ones
+func lineHeight(line cardLine) int {
+	if line.text == "" {
+		return line.size
+	}
+	return line.size * 3 / 2
+}
+
+// pngConverters lists the SVG to PNG converters to try, in order of
+// preference, each reading the SVG from stdin; OUT stands for the PNG path
+var pngConverters = [][]string{
+	{"rsvg-convert", "-f", "png", "-o", "OUT"},
+	{"magick", "svg:-", "OUT"},
+	{"inkscape", "--pipe", "--export-type=png", "--export-filename=OUT"},
+}
+
+// errNoConverter is returned when no SVG to PNG converter is installed
+var errNoConverter = errors.New("no SVG to PNG converter found")
+
+// writeCardFile writes the card of s to path as SVG, or as PNG for a .png
+// path by piping the SVG through the first available converter
+func (r renderer) writeCardFile(path string, s Sloka, background, foreground string) error {
+	var svg strings.Builder
+	if err := r.writeCard(&svg, s, background, foreground); err != nil {
+		return err
+	}
+	return writeImage(path, svg.String())
+}
+
+// writeImage writes svg to path, converted to PNG for a .png path by the
+// first available converter
+func writeImage(path, svg string) error {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".svg":
+		return os.WriteFile(path, []byte(svg), 0o644)
+	case ".png":
+		for _, args := range pngConverters {
+			if _, err := exec.LookPath(args[0]); err != nil {
+				continue
+			}
+			args = slices.Clone(args)
+			for i := range args {
+				args[i] = strings.ReplaceAll(args[i], "OUT", path)
+			}
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Stdin = strings.NewReader(svg)
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		}
+		return errNoConverter
+	default:
+		return fmt.Errorf("unsupported image type %q (expected .svg or .png)", ext)
+	}
+}
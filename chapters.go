@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// isLatin reports whether every letter of s is in the Latin script
+func isLatin(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLang normalizes a -lang value, accepting codes and English names in any case
+func parseLang(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "en", "english":
+		return "en", true
+	case "hi", "hindi":
+		return "hi", true
+	}
+	return "", false
+}
+
+// chapterName returns the chapter name to show for lang. English prefers a
+// Latin-script name, falling back from Name to Translation and then
+// Transliteration; Hindi prefers Name and falls back to the English forms.
+func chapterName(c Chapter, lang string) string {
+	if lang == "hi" {
+		return firstNonEmpty(c.Name, c.Translation, c.Transliteration)
+	}
+	if c.Name != "" && isLatin(c.Name) {
+		return c.Name
+	}
+	return firstNonEmpty(c.Translation, c.Transliteration, c.Name)
+}
+
+// localized picks the text for lang, falling back to the other language
+func localized(en, hi, lang string) string {
+	if lang == "hi" {
+		return firstNonEmpty(hi, en)
+	}
+	return firstNonEmpty(en, hi)
+}
+
+// firstNonEmpty returns the first non-empty string of values
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// printChapterList writes an aligned table of chapters with their verse
+// counts, Sanskrit names, Latin-script names and meanings, the meanings in
+// Hindi with lang "hi"
+func printChapterList(w io.Writer, chapters []Chapter, lang string) {
+	nameWidth, latinWidth := len("NAME"), len("TRANSLITERATION")
+	for _, c := range chapters {
+		nameWidth = max(nameWidth, textWidth(c.Name))
+		latinWidth = max(latinWidth, textWidth(chapterName(c, "en")))
+	}
+	pad := func(text string, width int) string {
+		return text + strings.Repeat(" ", max(width-textWidth(text), 0))
+	}
+
+	fmt.Fprintf(w, "%s%2s  %6s  %s  %s  %s%s\n", Bold, "#", "VERSES", pad("NAME", nameWidth),
+		pad("TRANSLITERATION", latinWidth), "MEANING", Reset)
+	for _, c := range chapters {
+		meaning := localized(c.Meaning.En, c.Meaning.Hi, lang)
+		fmt.Fprintf(w, "%2d  %6d  %s  %s  %s%s%s\n", c.ChapterNumber, c.VersesCount, pad(c.Name, nameWidth),
+			pad(chapterName(c, "en"), latinWidth), Dim, meaning, Reset)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// citation is a reference to verses as written: a chapter and a range of
+// its verses, or the whole chapter when the range is 0-0
+type citation struct {
+	chapter     int
+	first, last int
+}
+
+// readCitation reads ref as "2.47", "2:47", a range such as "2.20-25" or
+// "2.20-2.25", or a whole chapter as "2", optionally after a text's
+// abbreviation as in "bg2.47" or "BG 18:66". Chapter and verse numbers are
+// digits only and start at 1.
+func readCitation(ref string) (citation, error) {
+	invalid := fmt.Errorf("invalid citation %q", strings.TrimSpace(ref))
+	norm := strings.ToUpper(strings.Join(strings.Fields(ref), ""))
+	if n := citationPrefix(norm); n > 0 {
+		norm = strings.TrimPrefix(norm[n:], ".")
+	}
+	norm = strings.ReplaceAll(norm, ":", ".")
+
+	chapter, verses, hasVerse := strings.Cut(norm, ".")
+	first, last, isRange := strings.Cut(verses, "-")
+	// a range may repeat the chapter, as in 2.20-2.25
+	if c, v, ok := strings.Cut(last, "."); isRange && ok && c == chapter {
+		last = v
+	}
+	if !isRange {
+		last = first
+	}
+	if !citationNumber(chapter) || hasVerse && (!citationNumber(first) || !citationNumber(last)) {
+		return citation{}, invalid
+	}
+	var c citation
+	c.chapter, _ = strconv.Atoi(chapter)
+	if hasVerse {
+		c.first, _ = strconv.Atoi(first)
+		c.last, _ = strconv.Atoi(last)
+		if c.last < c.first {
+			return citation{}, fmt.Errorf("%w: verse %d is before %d", invalid, c.last, c.first)
+		}
+	}
+	return c, nil
+}
+
+// citationNumber reports whether s is a chapter or verse number of a
+// citation: digits only, and at least 1
+func citationNumber(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 1 && strings.Trim(s, "0123456789") == ""
+}
+
+// citationPrefix returns the length of the text abbreviation, such as "BG",
+// that ref starts with in any case, 0 for none
+func citationPrefix(ref string) int {
+	for _, t := range gita.Texts() {
+		if len(ref) >= len(t.Abbrev) && strings.EqualFold(ref[:len(t.Abbrev)], t.Abbrev) {
+			return len(t.Abbrev)
+		}
+	}
+	return 0
+}
+
+// citationArg splits the verse off the front of args: the first argument,
+// or the first two when the first is only a text's abbreviation, as in
+// "BG 18:66"
+func citationArg(args []string) (ref string, rest []string) {
+	if len(args) > 1 && args[0] != "" && citationPrefix(args[0]) == len(args[0]) {
+		return args[0] + " " + args[1], args[2:]
+	}
+	return args[0], args[1:]
+}
+
+// parseCitation returns the verses of book cited by ref, as readCitation
+// reads it. Verses of a range the book lacks are skipped; a range without
+// any of them is an error wrapping errNoVerse.
+func parseCitation(book gita.Scripture, ref string) ([]Sloka, error) {
+	c, err := readCitation(ref)
+	if err != nil {
+		return nil, err
+	}
+	if c.first == 0 {
+		if verses := book.Verses(c.chapter); len(verses) > 0 {
+			return inOrder(verses), nil
+		}
+		return nil, fmt.Errorf("%w: chapter %d", errNoVerse, c.chapter)
+	}
+
+	var slokas []Sloka
+	for verse := c.first; verse <= c.last; verse++ {
+		if s, ok := book.Get(c.chapter, verse); ok {
+			slokas = append(slokas, s)
+		}
+	}
+	if len(slokas) == 0 {
+		return nil, fmt.Errorf("%w: %s", errNoVerse, strings.TrimSpace(ref))
+	}
+	return slokas, nil
+}
+
+// parseVerse returns the one verse cited by ref, read as parseCitation
+// reads it, for the commands that act on a single verse
+func parseVerse(book gita.Scripture, ref string) (Sloka, error) {
+	slokas, err := parseCitation(book, ref)
+	if err != nil {
+		return Sloka{}, err
+	}
+	if len(slokas) != 1 {
+		return Sloka{}, fmt.Errorf("expected one verse, got %q", strings.TrimSpace(ref))
+	}
+	return slokas[0], nil
+}
+
+// readCitations returns the verses cited in r, one citation per line, in
+// the order given. Blank lines and lines starting with # are skipped, and
+// so are citations of missing verses, with a warning. Malformed lines are
+// an error naming the line.
+func readCitations(r io.Reader, book gita.Scripture) ([]Sloka, error) {
+	var slokas []Sloka
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cited, err := parseCitation(book, line)
+		if errors.Is(err, errNoVerse) {
+			fmt.Fprintf(os.Stderr, "Warning: line %d: %v\n", n, err)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		slokas = append(slokas, cited...)
+	}
+	return slokas, scanner.Err()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+)
+
+// values of -copy-format
+const (
+	CopyVerse       = "verse"       // the verse as printed
+	CopyTranslation = "translation" // the translation on one line with its citation
+)
+
+// clipboardScript puts the text passed in the environment on the Windows
+// clipboard; clip.exe would read it in the console code page, not UTF-8
+const clipboardScript = `Set-Clipboard -Value $env:GITASAY_CLIPBOARD`
+
+// clipboardCommands lists the clipboard tools to try on each platform, in
+// order of preference
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"powershell", "-NoProfile", "-Command", clipboardScript}, {"clip"}},
+	"linux":   {{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}},
+}
+
+// errNoClipboard is returned when no clipboard tool is installed
+var errNoClipboard = errors.New("no clipboard tool found")
+
+// copyToClipboard places text, without ANSI styling, on the system clipboard
+// using the first available tool for the platform
+func copyToClipboard(text string) error {
+	text = ansiEscape.ReplaceAllString(text, "")
+	for _, args := range clipboardCommands[runtime.GOOS] {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), "GITASAY_CLIPBOARD="+text)
+		cmd.Stdin = strings.NewReader(text)
+		if args[0] == "clip" {
+			// clip takes UTF-16 when the text starts with its byte order mark
+			cmd.Stdin = bytes.NewReader(utf16LE(text))
+		}
+		return cmd.Run()
+	}
+	return errNoClipboard
+}
+
+// utf16LE encodes text as UTF-16LE after a byte order mark
+func utf16LE(text string) []byte {
+	units := utf16.Encode([]rune("\ufeff" + text))
+	b := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// copiedTranslations returns the translation of each sloka on a single line,
+// followed by a line with its author and citation, ready to paste into a chat
+func copiedTranslations(slokas []Sloka, r renderer) string {
+	var b strings.Builder
+	for i, s := range slokas {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		text, author := r.resolve(s).translation(s)
+		fmt.Fprintf(&b, "%s\n— %s, %s %d.%d\n", cleanTranslation(text), author, r.text.Title, s.Chapter, s.Verse)
+	}
+	return b.String()
+}
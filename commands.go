@@ -0,0 +1,571 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// subcommand is a named task with its own help that accepts a subset of the
+// global flags. Flags parsed by a subcommand are mirrored onto the global
+// flag set, so the rest of main sees them as if given directly.
+type subcommand struct {
+	name    string
+	args    string            // positional arguments shown in the usage line
+	summary string            // one-line description
+	flags   []string          // global flags the subcommand accepts
+	implied map[string]string // global flags the subcommand sets
+	aliases map[string]string // flags of the subcommand's own, by the global flag they stand for
+	// positional handles the arguments left after the flags
+	positional func(args []string) error
+}
+
+// displayFlags are the flags that shape how verses are printed
+var displayFlags = []string{
+	"translation", "auto-source", "all-translations", "blind", "reveal",
+	"bilingual", "commentary", "cite", "context", "chapter-info", "chapter-summary",
+	"lang", "scheme", "strip-html", "wrap", "hyphenate", "justify", "width", "plain",
+	"plain-header", "highlight", "theme", "color", "no-color", "cow", "figure", "center", "box", "box-style",
+	"format", "json", "include-all-translations", "md", "commit-msg",
+	"status", "status-width", "oneline", "prefix", "suffix", "copy", "copy-format", "output", "o",
+	"buffered", "no-track", "no-pager", "no-notes", "related", "split", "animate", "animate-delay", "text", "data", "data-merge",
+}
+
+// subcommands lists the available subcommands; running without one keeps
+// the flat flag interface
+var subcommands = []subcommand{
+	{
+		name:       "random",
+		args:       "[CHAPTER]",
+		summary:    "Show a random verse, from CHAPTER (a number or name) if given, as gitasay does without a command.",
+		flags:      append([]string{"c", "chapters", "n", "seed", "select", "topic", "rotate", "no-repeat", "fav-random", "speak", "voice", "speak-rate", "speak-url"}, displayFlags...),
+		positional: randomArgs,
+	},
+	{
+		name:       "verse",
+		args:       "[CHAPTER[.VERSE[-VERSE]] | BG CHAPTER:VERSE]",
+		summary:    "Show a verse: the given one, or a random one (from CHAPTER if given).",
+		flags:      append([]string{"c", "v", "id", "stdin", "chapters", "n", "seed", "select", "topic", "no-repeat", "interactive", "browse", "tui", "speak", "voice", "speak-rate", "speak-url"}, displayFlags...),
+		positional: verseArgs,
+	},
+	{
+		name:       "compare",
+		args:       "VERSE VERSE",
+		summary:    "Show two verses side by side, or one after the other on narrow terminals.",
+		flags:      displayFlags,
+		positional: compareArgs,
+	},
+	{
+		name:    "pick",
+		summary: "Choose the verse to show from a fuzzy-searchable list, with fzf when installed.",
+		flags:   append([]string{"c", "chapters", "topic", "fav-random"}, displayFlags...),
+		implied: map[string]string{"pick": "true"},
+	},
+	{
+		name:       "plan",
+		args:       "[start | today | status]",
+		summary:    "Follow a reading plan of every verse: start one, read today's verses or see the progress.",
+		flags:      append([]string{"days", "start", "per-day", "date", "daily-tz"}, displayFlags...),
+		positional: planArgs,
+	},
+	{
+		name:    "stats",
+		summary: "Show your streak, the verses viewed and the most viewed chapters.",
+		flags:   []string{"json", "lang", "daily-tz", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"usage-stats": "true"},
+	},
+	{
+		name:    "feed",
+		summary: "Write an Atom or RSS feed of the verses of the day.",
+		flags: []string{"days", "feed-format", "feed-url", "c", "date", "daily-tz", "translation", "auto-source",
+			"all-translations", "lang", "scheme", "strip-html", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"feed": "true"},
+	},
+	{
+		name:    "define",
+		args:    "[WORD]",
+		summary: "Show the meaning of a Sanskrit term and the verses it occurs in, or list the terms.",
+		flags: []string{"n", "json", "translation", "auto-source", "lang", "scheme", "wrap", "width",
+			"plain-header", "highlight", "theme", "no-color", "output", "o", "text", "data", "data-merge"},
+		positional: defineArgs,
+	},
+	{
+		name:    "concordance",
+		args:    "WORD | -stats",
+		summary: "List every verse whose Sanskrit contains WORD, or with -stats each chapter's most frequent words.",
+		flags: []string{"stats", "c", "limit", "scheme", "json", "lang", "width", "no-color",
+			"no-pager", "output", "o", "text", "data", "data-merge"},
+		positional: concordanceArgs,
+	},
+	{
+		name:    "search",
+		args:    "TERM...",
+		summary: "List the verses containing TERM.",
+		flags:   append([]string{"search-in", "limit", "search-random", "seed"}, displayFlags...),
+		positional: func(args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("missing search term")
+			}
+			return flag.Set("search", strings.Join(args, " "))
+		},
+	},
+	{
+		name:    "chapters",
+		summary: "List all chapters.",
+		flags:   []string{"lang", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"list-chapters": "true"},
+	},
+	{
+		name:    "chapter",
+		args:    "CHAPTER",
+		summary: "Show a chapter's name and meaning, and with -summary its summary.",
+		flags:   []string{"summary", "lang", "width", "wrap", "plain-header", "no-color", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"chapter-only": "true"},
+		positional: func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one chapter number")
+			}
+			if err := flag.Set("c", args[0]); err != nil {
+				return fmt.Errorf("invalid chapter %q", args[0])
+			}
+			return nil
+		},
+	},
+	{
+		name:    "topics",
+		summary: "List the topics with their verse counts.",
+		flags:   []string{"json", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"list-topics": "true"},
+	},
+	{
+		name:    "occasions",
+		summary: "List the occasions with the next day of each.",
+		flags:   []string{"json", "date", "daily-tz", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"list-occasions": "true"},
+	},
+	{
+		name:    "daily",
+		summary: "Show the verse of the day.",
+		flags:   append([]string{"c", "chapters", "occasion", "date", "daily-tz", "speak", "voice", "speak-rate", "speak-url"}, displayFlags...),
+		implied: map[string]string{"daily": "true"},
+	},
+	{
+		name:    "quiz",
+		summary: "Quiz yourself on the chapter and verse, or the words, of verses.",
+		flags: []string{"c", "topic", "n", "seed", "quiz-mode", "translation", "auto-source", "lang",
+			"scheme", "strip-html", "wrap", "width", "plain-header", "highlight", "theme", "no-color", "text", "data", "data-merge"},
+		implied: map[string]string{"quiz": "true"},
+	},
+	{
+		name:    "review",
+		summary: "Review the favorites that are due, with spaced repetition.",
+		flags: []string{"n", "date", "translation", "lang", "scheme", "wrap", "width", "plain-header",
+			"highlight", "theme", "no-color", "text", "data", "data-merge"},
+		implied: map[string]string{"review": "true"},
+	},
+	{
+		name:    "notify",
+		summary: "Post a random verse as a desktop notification at an interval or a daily time.",
+		flags:   []string{"every", "at", "c", "chapters", "topic", "fav-random", "seed", "translation", "auto-source", "text", "data", "data-merge"},
+		implied: map[string]string{"notify": "true"},
+	},
+	{
+		name:    "serve",
+		summary: "Serve verses as a JSON HTTP API, or as a Gemini capsule.",
+		flags: []string{"host", "port", "protocol", "tls-cert", "tls-key", "date", "daily-tz", "lang", "translation", "auto-source", "width", "wrap", "strip-html",
+			"cite", "commentary", "word-meanings", "seed", "text", "data", "data-merge"},
+		implied: map[string]string{"serve": "true"},
+	},
+	{
+		name:    "export",
+		args:    "txt | json | md | html | epub | fortune",
+		summary: "Export the whole book, or one chapter, in order.",
+		flags: []string{"c", "format", "strfile", "translation", "auto-source", "include-all-translations",
+			"lang", "scheme", "width", "wrap", "strip-html", "no-pager", "output", "o", "buffered", "text", "data", "data-merge"},
+		aliases:    map[string]string{"out": "output"},
+		positional: exportArgs,
+	},
+	{
+		name:    "bot",
+		summary: "Post the verse of the day to a Slack, Discord or Telegram chat.",
+		flags: []string{"bot-url", "bot-platform", "bot-chat", "bot-retries", "dry-run", "c", "chapters",
+			"date", "daily-tz", "translation", "auto-source", "scheme", "output", "o", "text", "data", "data-merge"},
+		implied: map[string]string{"bot": "true", "daily": "true"},
+	},
+	{
+		name:    "wallpaper",
+		args:    "[FILE]",
+		summary: "Write the verse of the day onto a desktop wallpaper, and optionally set it.",
+		flags: []string{"resolution", "set-wallpaper", "wallpaper-image", "image-bg", "image-fg", "c", "chapters",
+			"date", "daily-tz", "translation", "auto-source", "scheme", "text", "data", "data-merge"},
+		implied: map[string]string{"daily": "true"},
+		aliases: map[string]string{"out": "wallpaper", "set": "set-wallpaper"},
+		positional: func(args []string) error {
+			switch {
+			case len(args) == 0 && flagSet("wallpaper"):
+				return nil
+			case len(args) != 1 || flagSet("wallpaper"):
+				return fmt.Errorf("expected the file to write (.svg or .png), as FILE or -out FILE")
+			}
+			return flag.Set("wallpaper", args[0])
+		},
+	},
+	{
+		name:    "update-data",
+		summary: "Download the latest curated dataset, used from then on instead of the embedded one.",
+		flags:   []string{"update-url"},
+		implied: map[string]string{"update-data": "true"},
+	},
+	{
+		name:    "doctor",
+		summary: "Check the dataset in use for problems, exiting with status 4 when it has errors.",
+		flags:   []string{"json", "text", "data", "data-merge"},
+		implied: map[string]string{"doctor": "true"},
+	},
+	{
+		name:       "cache",
+		args:       "status | clear",
+		summary:    "Show or remove the cached API verses and recitations.",
+		flags:      []string{"json", "cache-ttl"},
+		positional: cacheArgs,
+	},
+	{
+		name:       "hook",
+		args:       "install | uninstall [prepare-commit-msg | post-commit]",
+		summary:    "Install a git hook that adds a verse to commit messages, or prints one after each commit.",
+		flags:      append([]string{"force"}, hookFlags...),
+		positional: hookCommandArgs,
+	},
+	{
+		name:    "completion",
+		args:    "bash | zsh | fish",
+		summary: "Print a shell completion script.",
+		positional: func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one shell (bash, zsh or fish)")
+			}
+			return flag.Set("completion", args[0])
+		},
+	},
+	{
+		name:       "note",
+		args:       "add VERSE [TEXT] | show VERSE | rm VERSE | list",
+		summary:    "Write personal notes on verses, shown under them whenever they are printed.",
+		flags:      []string{"lang", "width", "wrap", "no-color", "output", "o", "text", "data", "data-merge"},
+		positional: noteArgs,
+	},
+	{
+		name:       "fav",
+		args:       "add VERSE | rm VERSE | list | random | sync",
+		summary:    "Bookmark verses, list them, show a random one or sync them across machines.",
+		flags:      append([]string{"n", "seed", "select", "no-repeat", "sync-backend", "sync-url", "sync-token", "force"}, displayFlags...),
+		positional: favArgs,
+	},
+}
+
+// randomArgs takes the chapter to draw the random verse from, if any, by
+// number or by name as -c does
+func randomArgs(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	chapter := strings.Join(args, " ")
+	if _, err := strconv.Atoi(chapter); err != nil && strings.ContainsAny(chapter, ".:0123456789") {
+		return fmt.Errorf("expected a chapter, got %q; 'gitasay verse' shows a given verse", chapter)
+	}
+	return flag.Set("c", chapter)
+}
+
+// exportArgs takes the export format as the argument or, for "export
+// -format html", from -format, which then no longer applies to the verse
+func exportArgs(args []string) error {
+	switch {
+	case len(args) == 1:
+		return flag.Set("export", args[0])
+	case len(args) == 0 && flagSet("format"):
+		format := flag.Lookup("format")
+		if err := flag.Set("export", format.Value.String()); err != nil {
+			return err
+		}
+		return format.Value.Set(format.DefValue)
+	}
+	return fmt.Errorf("expected one format (%s)", strings.Join(exportFormats, ", "))
+}
+
+// planArgs maps "start", "today" and "status" onto the -plan flags; with no
+// action the plan is printed as with -plan
+func planArgs(args []string) error {
+	switch {
+	case len(args) == 0:
+		return flag.Set("plan", "true")
+	case len(args) > 1:
+		return fmt.Errorf("expected one action (start, today or status)")
+	}
+	switch args[0] {
+	case "start", "today", "status":
+		return flag.Set("plan-"+args[0], "true")
+	}
+	return fmt.Errorf("unknown action %q (start, today or status)", args[0])
+}
+
+// defineArgs takes the term to define, or lists the terms without one
+func defineArgs(args []string) error {
+	switch len(args) {
+	case 0:
+		return flag.Set("list-terms", "true")
+	case 1:
+		return flag.Set("define", args[0])
+	}
+	return fmt.Errorf("expected one word, got %q", strings.Join(args, " "))
+}
+
+// compareArgs selects the first verse as verseArgs does and compares it
+// with the second
+func compareArgs(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected two verses, e.g. 2.47 18.66")
+	}
+	if err := verseArgs(args[:1]); err != nil {
+		return err
+	}
+	return flag.Set("compare", args[1])
+}
+
+// concordanceArgs takes the word to look up or, for "concordance -stats",
+// lists the frequent words, -stats then no longer printing the dataset
+// summary
+func concordanceArgs(args []string) error {
+	switch {
+	case len(args) == 1 && !flagSet("stats"):
+		return flag.Set("concordance", args[0])
+	case len(args) == 0 && flagSet("stats"):
+		stats := flag.Lookup("stats")
+		if err := stats.Value.Set(stats.DefValue); err != nil {
+			return err
+		}
+		return flag.Set("word-freq", "true")
+	}
+	return fmt.Errorf("expected one word, or -stats")
+}
+
+// cacheArgs maps "status" and "clear" onto -cache-status and -cache-clear
+func cacheArgs(args []string) error {
+	if len(args) != 1 || (args[0] != "status" && args[0] != "clear") {
+		return fmt.Errorf("expected status or clear")
+	}
+	return flag.Set("cache-"+args[0], "true")
+}
+
+// hookCommandArgs maps "install" and "uninstall", followed by the hook
+// (prepare-commit-msg when omitted), onto -hook-install and -hook-uninstall
+func hookCommandArgs(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("expected install or uninstall, and optionally the hook (%s)", strings.Join(hookTypes, " or "))
+	}
+	kind := HookPrepareCommitMsg
+	if len(args) == 2 {
+		kind = args[1]
+	}
+	switch args[0] {
+	case "install", "uninstall":
+		return flag.Set("hook-"+args[0], kind)
+	}
+	return fmt.Errorf("unknown action %q (install or uninstall)", args[0])
+}
+
+// noteArgs maps "add 2.47 TEXT", "show 2.47", "rm 2.47" and "list" onto
+// the -note flags; the words after the verse of add are the note's text
+func noteArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing action (add, show, rm or list)")
+	}
+	switch action, rest := args[0], args[1:]; action {
+	case "add":
+		if len(rest) == 0 {
+			return fmt.Errorf("add expects a verse and the note, e.g. 2.47 \"my reflection\"")
+		}
+		ref, text := citationArg(rest)
+		if len(text) > 0 {
+			if err := flag.Set("note", strings.Join(text, " ")); err != nil {
+				return err
+			}
+		}
+		return flag.Set("note-add", ref)
+	case "show", "rm":
+		if len(rest) == 0 {
+			return fmt.Errorf("%s expects one verse, e.g. 2.47", action)
+		}
+		if ref, more := citationArg(rest); len(more) == 0 {
+			return flag.Set("note-"+action, ref)
+		}
+		return fmt.Errorf("%s expects one verse, e.g. 2.47", action)
+	case "list":
+		if len(rest) != 0 {
+			return fmt.Errorf("list takes no arguments")
+		}
+		return flag.Set("note-list", "true")
+	default:
+		return fmt.Errorf("unknown action %q (add, show, rm or list)", action)
+	}
+}
+
+// favArgs maps "add 2:47", "rm 2:47", "list", "random" and "sync" onto the
+// -fav flags
+func favArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing action (add, rm, list, random or sync)")
+	}
+	switch action, rest := args[0], args[1:]; action {
+	case "add", "rm":
+		if len(rest) == 0 {
+			return fmt.Errorf("%s expects one verse, e.g. 2:47", action)
+		}
+		if ref, more := citationArg(rest); len(more) == 0 {
+			return flag.Set("fav-"+action, ref)
+		}
+		return fmt.Errorf("%s expects one verse, e.g. 2:47", action)
+	case "list", "random", "sync":
+		if len(rest) != 0 {
+			return fmt.Errorf("%s takes no arguments", action)
+		}
+		return flag.Set("fav-"+action, "true")
+	default:
+		return fmt.Errorf("unknown action %q (add, rm, list, random or sync)", action)
+	}
+}
+
+// verseArgs selects the verses given as a citation, read as readCitation
+// reads it, e.g. "2", "2.47", "2.20-25", "bg2.47" or "BG 18:66"
+func verseArgs(args []string) error {
+	switch {
+	case len(args) == 0:
+		return nil
+	case len(args) > 2, len(args) == 2 && citationPrefix(args[0]) != len(args[0]):
+		return fmt.Errorf("expected one verse, got %q", strings.Join(args, " "))
+	}
+	c, err := readCitation(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	// chapters are cited by number; names are given with -c
+	if err := flag.Set("c", strconv.Itoa(c.chapter)); err != nil {
+		return err
+	}
+	switch {
+	case c.first == 0:
+		return nil
+	case c.first == c.last:
+		return flag.Set("v", strconv.Itoa(c.first))
+	}
+	return flag.Set("v", fmt.Sprintf("%d-%d", c.first, c.last))
+}
+
+// parseArgs parses the command line, dispatching to a subcommand when the
+// first argument names one
+func parseArgs(args []string) {
+	flag.Usage = usage
+	if len(args) > 0 {
+		for _, c := range subcommands {
+			if c.name == args[0] {
+				c.parse(args[1:])
+				return
+			}
+		}
+	}
+	flag.CommandLine.Init("gitasay", flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		flagError(flag.CommandLine, err)
+	}
+
+	// a verse citation may come before, between or after the flags
+	var rest []string
+	for flag.NArg() > 0 {
+		rest = append(rest, flag.Arg(0))
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			flagError(flag.CommandLine, err)
+		}
+	}
+	if err := verseArgs(rest); err != nil {
+		var names []string
+		for _, c := range subcommands {
+			names = append(names, c.name)
+		}
+		if s := closest(rest[0], names); s != "" {
+			fmt.Fprintf(os.Stderr, "gitasay: unknown command %q; did you mean '%s'?\n", rest[0], s)
+		} else {
+			fmt.Fprintf(os.Stderr, "gitasay: %v (see 'gitasay -h' for the commands)\n", err)
+		}
+		os.Exit(exitUsage)
+	}
+}
+
+// parse parses args with the subcommand's own flag set and help
+func (c subcommand) parse(args []string) {
+	fs := flag.NewFlagSet("gitasay "+c.name, flag.ContinueOnError)
+	for _, name := range c.flags {
+		f := flag.CommandLine.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	for name, global := range c.aliases {
+		fs.Var(flag.CommandLine.Lookup(global).Value, name, "Shorthand for -"+global)
+	}
+	fs.Usage = func() {
+		line := strings.TrimSpace("gitasay " + c.name + " [flags] " + c.args)
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\n%s\n\nFlags:\n", line, c.summary)
+		fs.PrintDefaults()
+	}
+
+	// flags may come before or after the positional arguments
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			flagError(fs, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		rest, args = append(rest, args[0]), args[1:]
+	}
+
+	// mirror the flags onto the global set so flag.Visit reports them
+	fs.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if global, ok := c.aliases[name]; ok {
+			name = global
+		}
+		flag.Set(name, f.Value.String())
+	})
+	for name, value := range c.implied {
+		flag.Set(name, value)
+	}
+
+	if c.positional == nil && len(rest) > 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	if c.positional != nil {
+		if err := c.positional(rest); err != nil {
+			fmt.Fprintf(os.Stderr, "gitasay %s: %v\n", c.name, err)
+			os.Exit(exitUsage)
+		}
+	}
+}
+
+// usage prints the global help: the subcommands, then every flag
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: gitasay [command] [flags]\n\nCommands:\n")
+	// the summaries line up after the longest name
+	width := 0
+	for _, c := range subcommands {
+		width = max(width, len(c.name))
+	}
+	for _, c := range subcommands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'gitasay COMMAND -h' for the flags of a command. Without a command a\nrandom verse is shown, or the one cited, e.g. gitasay 2.47.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compareMinWidth is the narrowest -width at which -compare sets the two
+// verses side by side; below it they are shown one after the other
+const compareMinWidth = 100
+
+// compareBlocks returns the parts of s that -compare lines up across the
+// columns: the header, the Sanskrit, the transliteration and the
+// translation with its author, each wrapped to r.width
+func (r renderer) compareBlocks(s Sloka) [][]string {
+	r = r.resolve(s)
+	lines := func(style, text string) []string {
+		return strings.Split(r.highlighted(style, r.wrap(text)), "\n")
+	}
+
+	header := []string{r.header(fmt.Sprintf("%s %d, %s %d", label("Chapter", r.lang), s.Chapter, label("Verse", r.lang), s.Verse))}
+	var sanskrit, transliteration []string
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if strings.TrimSpace(line) != "" {
+			sanskrit = append(sanskrit, lines(SanskritStyle, strings.TrimSpace(line))...)
+		}
+	}
+	for _, line := range transliterationLines(r.transliteration(s)) {
+		transliteration = append(transliteration, lines(TransliterationStyle, line)...)
+	}
+	translation := []string{Dim + tr(r.lang, "(no translation available for this verse)") + Reset}
+	if text, author := r.translation(s); strings.TrimSpace(text) != "" {
+		translation = append(lines(TranslationStyle, text), AuthorStyle+"("+author+")"+Reset)
+	}
+	return [][]string{header, sanskrit, transliteration, translation}
+}
+
+// compare writes a and b in two columns, each part of one verse level with
+// the same part of the other so parallel teachings read across. When the
+// width leaves too little room for two columns, the verses are written one
+// after the other.
+func (r renderer) compare(w io.Writer, a, b Sloka) {
+	if r.width < compareMinWidth {
+		r.sloka(w, a)
+		r.sloka(w, b)
+		return
+	}
+	r.width = (r.width - columnGap) / 2
+	left, right := r.compareBlocks(a), r.compareBlocks(b)
+
+	var leftLines, rightLines []string
+	for i := range left {
+		height := max(len(left[i]), len(right[i]))
+		for j := 0; j < height; j++ {
+			leftLines = append(leftLines, lineAt(left[i], j))
+			rightLines = append(rightLines, lineAt(right[i], j))
+		}
+		leftLines, rightLines = append(leftLines, ""), append(rightLines, "")
+	}
+	fmt.Fprint(w, columns(leftLines, rightLines, r.width))
+}
+
+// lineAt returns lines[i], or an empty line past the end
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
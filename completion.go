@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isBoolFlag reports whether f takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// completion describes what a completion script offers
+type completion struct {
+	flags    []*flag.Flag
+	values   map[string][]string // candidate values of flags by name
+	commands []string            // subcommands offered as the first word
+	// verseCounts holds the verse count of each chapter, index 0 for chapter 1;
+	// -c shows them as hints and -v offers only the verses of the chapter given
+	verseCounts []int
+}
+
+// completionScript returns a completion script for shell covering every
+// flag of fs, with candidate values for the flags listed in values, the
+// subcommands, and the verses of each chapter
+func completionScript(shell string, fs *flag.FlagSet, values map[string][]string, commands []string, verseCounts []int) (string, error) {
+	c := completion{values: values, commands: commands, verseCounts: verseCounts}
+	fs.VisitAll(func(f *flag.Flag) { c.flags = append(c.flags, f) })
+	sort.Slice(c.flags, func(i, j int) bool { return c.flags[i].Name < c.flags[j].Name })
+
+	switch shell {
+	case "bash":
+		return c.bash(), nil
+	case "zsh":
+		return c.zsh(), nil
+	case "fish":
+		return c.fish(), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q", shell)
+}
+
+// counts returns the verse counts as space-separated words
+func (c completion) counts() string {
+	words := make([]string, len(c.verseCounts))
+	for i, n := range c.verseCounts {
+		words[i] = strconv.Itoa(n)
+	}
+	return strings.Join(words, " ")
+}
+
+func (c completion) bash() string {
+	var b strings.Builder
+	b.WriteString("# bash completion for gitasay\n")
+	b.WriteString("_gitasay() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	if len(c.commands) > 0 {
+		b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ] && [[ $cur != -* ]]; then\n")
+		fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\")); return\n", strings.Join(c.commands, " "))
+		b.WriteString("    fi\n")
+	}
+	b.WriteString("    case \"${prev#-}\" in\n")
+	if len(c.verseCounts) > 0 {
+		// offer the verses of the chapter given with -c
+		b.WriteString("        v|-v)\n")
+		b.WriteString("            local i chapter=0\n")
+		b.WriteString("            for ((i = 1; i < COMP_CWORD - 1; i++)); do\n")
+		b.WriteString("                case \"${COMP_WORDS[i]}\" in -c|--c) chapter=\"${COMP_WORDS[i+1]}\" ;; esac\n")
+		b.WriteString("            done\n")
+		b.WriteString("            [[ $chapter =~ ^[0-9]+$ ]] || chapter=0\n")
+		fmt.Fprintf(&b, "            local counts=(0 %s)\n", c.counts())
+		b.WriteString("            COMPREPLY=($(compgen -W \"$(seq 1 \"${counts[chapter]:-0}\") all\" -- \"$cur\")); return ;;\n")
+	}
+	var names []string
+	for _, f := range c.flags {
+		names = append(names, "-"+f.Name)
+		if isBoolFlag(f) || f.Name == "v" && len(c.verseCounts) > 0 {
+			continue
+		}
+		if vals, ok := c.values[f.Name]; ok {
+			fmt.Fprintf(&b, "        %s|-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.Name, f.Name, strings.Join(vals, " "))
+		} else {
+			fmt.Fprintf(&b, "        %s|-%s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", f.Name, f.Name)
+		}
+	}
+	b.WriteString("    esac\n")
+	fmt.Fprintf(&b, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	b.WriteString("}\n")
+	b.WriteString("complete -F _gitasay gitasay\n")
+	return b.String()
+}
+
+// zshEscape escapes text for use inside a single-quoted _arguments spec
+var zshEscape = strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+
+func (c completion) zsh() string {
+	var b strings.Builder
+	b.WriteString("#compdef gitasay\n")
+	if len(c.verseCounts) > 0 {
+		// offer the verses of the chapter given with -c
+		b.WriteString("_gitasay_verses() {\n")
+		fmt.Fprintf(&b, "  local -a counts=(%s)\n", c.counts())
+		b.WriteString("  local i=${words[(I)-c]} chapter\n")
+		b.WriteString("  (( i )) && chapter=${words[i+1]}\n")
+		b.WriteString("  [[ $chapter == <-> ]] && (( chapter >= 1 && chapter <= $#counts )) || return 1\n")
+		b.WriteString("  compadd -- {1..$counts[chapter]} all\n")
+		b.WriteString("}\n")
+	}
+	b.WriteString("_arguments \\\n")
+	if len(c.commands) > 0 {
+		fmt.Fprintf(&b, "  '1::command:(%s)' \\\n", strings.Join(c.commands, " "))
+	}
+	for _, f := range c.flags {
+		spec := fmt.Sprintf("-%s[%s]", f.Name, zshEscape.Replace(f.Usage))
+		switch {
+		case isBoolFlag(f):
+		case f.Name == "v" && len(c.verseCounts) > 0:
+			spec += ":verse:_gitasay_verses"
+		case f.Name == "c" && len(c.verseCounts) > 0:
+			// each chapter with its verse count as the description
+			var hints []string
+			for i, n := range c.verseCounts {
+				hints = append(hints, fmt.Sprintf(`%d\:"%d verses"`, i+1, n))
+			}
+			spec += fmt.Sprintf(":chapter:((%s))", strings.Join(hints, " "))
+		default:
+			if vals, ok := c.values[f.Name]; ok {
+				spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(vals, " "))
+			} else {
+				spec += fmt.Sprintf(":%s:_files", f.Name)
+			}
+		}
+		fmt.Fprintf(&b, "  '%s' \\\n", spec)
+	}
+	b.WriteString("  && return 0\n")
+	return b.String()
+}
+
+// fishEscape escapes text for use inside a single-quoted fish string
+var fishEscape = strings.NewReplacer(`\`, `\\`, "'", `\'`)
+
+func (c completion) fish() string {
+	var b strings.Builder
+	b.WriteString("# fish completion for gitasay\n")
+	b.WriteString("complete -c gitasay -f\n")
+	if len(c.commands) > 0 {
+		fmt.Fprintf(&b, "complete -c gitasay -n __fish_use_subcommand -a '%s'\n", strings.Join(c.commands, " "))
+	}
+	if len(c.verseCounts) > 0 {
+		// chapters with their verse counts, and the verses of the chapter given with -c
+		b.WriteString("function __gitasay_chapters\n")
+		for i, n := range c.verseCounts {
+			fmt.Fprintf(&b, "    printf '%%s\\t%%s\\n' %d '%d verses'\n", i+1, n)
+		}
+		b.WriteString("end\n")
+		b.WriteString("function __gitasay_verses\n")
+		fmt.Fprintf(&b, "    set -l counts %s\n", c.counts())
+		b.WriteString("    set -l args (commandline -opc)\n")
+		b.WriteString("    set -l chapter 0\n")
+		b.WriteString("    for i in (seq 2 (count $args))\n")
+		b.WriteString("        if test \"$args[(math $i - 1)]\" = -c\n")
+		b.WriteString("            set chapter $args[$i]\n")
+		b.WriteString("        end\n")
+		b.WriteString("    end\n")
+		b.WriteString("    string match -qr '^[0-9]+$' -- $chapter; or return\n")
+		b.WriteString("    test $chapter -ge 1 -a $chapter -le (count $counts); or return\n")
+		b.WriteString("    seq $counts[$chapter]\n")
+		b.WriteString("    echo all\n")
+		b.WriteString("end\n")
+	}
+	for _, f := range c.flags {
+		fmt.Fprintf(&b, "complete -c gitasay -o %s -d '%s'", f.Name, fishEscape.Replace(f.Usage))
+		switch {
+		case isBoolFlag(f):
+		case f.Name == "v" && len(c.verseCounts) > 0:
+			b.WriteString(" -x -a '(__gitasay_verses)'")
+		case f.Name == "c" && len(c.verseCounts) > 0:
+			b.WriteString(" -x -a '(__gitasay_chapters)'")
+		default:
+			if vals, ok := c.values[f.Name]; ok {
+				fmt.Fprintf(&b, " -x -a '%s'", strings.Join(vals, " "))
+			} else {
+				b.WriteString(" -r -F")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// topWords is how many of the most frequent words -word-freq lists per
+// chapter unless -limit says otherwise
+const topWords = 5
+
+// posting is one occurrence of a word in the verses
+type posting struct {
+	sloka int    // index of the verse in the indexed slokas
+	form  string // the word as written in the Sanskrit
+}
+
+// wordIndex is an inverted index of the Sanskrit words of some verses. Words
+// are keyed by their IAST transliteration folded to plain lowercase ASCII,
+// so a query matches whatever scheme and diacritics it was typed with.
+type wordIndex struct {
+	slokas []Sloka
+	words  map[string][]posting
+}
+
+// buildIndex indexes every word of the Sanskrit of slokas
+func buildIndex(slokas []Sloka) *wordIndex {
+	idx := &wordIndex{slokas: slokas, words: make(map[string][]posting)}
+	for i, s := range slokas {
+		for _, form := range sanskritWords(s.Slok) {
+			iast, _ := transliterate(form, SchemeIAST)
+			if key := foldIAST(iast); key != "" {
+				idx.words[key] = append(idx.words[key], posting{i, form})
+			}
+		}
+	}
+	return idx
+}
+
+// sanskritWords splits Devanagari text into its words, dropping the dandas
+// and verse numbers between them
+func sanskritWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r < 0x0900 || r > 0x097f || r == '।' || r == '॥' || r >= '०' && r <= '९'
+	})
+}
+
+// iastFolds maps the IAST letters with diacritics to their plain letter
+var iastFolds = strings.NewReplacer(
+	"ā", "a", "ī", "i", "ū", "u", "ṛ", "r", "ṝ", "r", "ḷ", "l", "ḹ", "l",
+	"ṃ", "m", "ḥ", "h", "ṅ", "n", "ñ", "n", "ṭ", "t", "ḍ", "d", "ṇ", "n",
+	"ś", "s", "ṣ", "s", "̐", "", "'", "",
+)
+
+// foldIAST lowercases IAST text and drops its diacritics, keeping only
+// letters
+func foldIAST(text string) string {
+	text = iastFolds.Replace(strings.ToLower(text))
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// normalizeWord returns the index key for a word typed in Devanagari or
+// romanized in scheme, IAST when empty. The romanization is read back into
+// IAST with the transliteration tables, so "sthitaprajJa" in Harvard-Kyoto
+// and "sthitapraj~na" in ITRANS both give "sthitaprajna".
+func normalizeWord(word, scheme string) string {
+	word = strings.TrimSpace(word)
+	if strings.ContainsFunc(word, func(r rune) bool { return r >= 0x0900 && r <= 0x097f }) {
+		iast, _ := transliterate(word, SchemeIAST)
+		return foldIAST(iast)
+	}
+	col := schemeColumn(scheme)
+	if col <= 0 {
+		return foldIAST(word)
+	}
+
+	// decode with the longest romanization matching at each position
+	tokens := make(map[string]string)
+	longest := 0
+	for _, table := range []map[rune][4]string{devanagariConsonants, devanagariVowels, devanagariMarks} {
+		for _, roman := range table {
+			if _, taken := tokens[roman[col]]; !taken {
+				tokens[roman[col]] = roman[0]
+				longest = max(longest, len(roman[col]))
+			}
+		}
+	}
+	var b strings.Builder
+	for rest := word; rest != ""; {
+		n := min(longest, len(rest))
+		for ; n > 0; n-- {
+			if iast, ok := tokens[rest[:n]]; ok {
+				b.WriteString(iast)
+				break
+			}
+		}
+		if n == 0 {
+			b.WriteByte(rest[0])
+			n = 1
+		}
+		rest = rest[n:]
+	}
+	return foldIAST(b.String())
+}
+
+// ConcordanceJSON lists the occurrences of a word
+type ConcordanceJSON struct {
+	Word        string      `json:"word"`        // the normalized query
+	Occurrences int         `json:"occurrences"` // counting repeats within a verse
+	Forms       []FormCount `json:"forms"`       // the words containing it, most frequent first
+	Verses      []string    `json:"verses"`      // references such as "2.47"
+}
+
+// FormCount is a word with how often it occurs
+type FormCount struct {
+	Form  string `json:"form"` // in IAST, or the -scheme romanization
+	Count int    `json:"count"`
+}
+
+// concordance is the result of looking a word up in the index
+type concordance struct {
+	ConcordanceJSON
+	slokas []Sloka
+	forms  []string // the matched words as displayed, for highlighting
+}
+
+// lookup returns the occurrences of the words whose key contains key, so a
+// stem also finds its inflections and the compounds and sandhi it is part
+// of. Forms are romanized in scheme.
+func (idx *wordIndex) lookup(key, scheme string) concordance {
+	c := concordance{ConcordanceJSON: ConcordanceJSON{Word: key}}
+	counts := make(map[string]int)
+	seen := make(map[int]bool)
+	for word, postings := range idx.words {
+		if !strings.Contains(word, key) {
+			continue
+		}
+		for _, p := range postings {
+			form, _ := transliterate(p.form, scheme)
+			counts[form]++
+			c.Occurrences++
+			seen[p.sloka] = true
+		}
+	}
+	for i, s := range idx.slokas {
+		if seen[i] {
+			c.slokas = append(c.slokas, s)
+			c.Verses = append(c.Verses, fmt.Sprintf("%d.%d", s.Chapter, s.Verse))
+		}
+	}
+	c.Forms = sortedCounts(counts)
+	for _, f := range c.Forms {
+		c.forms = append(c.forms, f.Form)
+	}
+	return c
+}
+
+// sortedCounts returns counts most frequent first, ties in alphabetical order
+func sortedCounts(counts map[string]int) []FormCount {
+	forms := make([]FormCount, 0, len(counts))
+	for form, n := range counts {
+		forms = append(forms, FormCount{form, n})
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		if forms[i].Count != forms[j].Count {
+			return forms[i].Count > forms[j].Count
+		}
+		return forms[i].Form < forms[j].Form
+	})
+	return forms
+}
+
+// printConcordance writes the summary of c, then a line per verse with the
+// transliteration around the first match, every matched word highlighted
+func printConcordance(w io.Writer, c concordance, r renderer, scheme string) {
+	fmt.Fprintf(w, "%s%s%s: %d occurrences in %d verses, %d forms\n\n", Bold, c.Word, Reset, c.Occurrences, len(c.Verses), len(c.Forms))
+	forms := make([]string, len(c.forms))
+	for i, f := range c.forms {
+		forms[i] = regexp.QuoteMeta(f)
+	}
+	// longest first, so a form is not highlighted as part of a shorter one
+	sort.Slice(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	pattern := regexp.MustCompile(strings.Join(forms, "|"))
+
+	matches := make([]searchMatch, len(c.slokas))
+	for i, s := range c.slokas {
+		text, _ := transliterate(s.Slok, scheme)
+		matches[i] = searchMatch{sloka: s, text: strings.Join(strings.Fields(text), " ")}
+	}
+	printSearchResults(w, matches, r, pattern)
+}
+
+// stopWords are the particles and pronouns too common to tell chapters
+// apart, with the speaker lines' "uvāca", as index keys
+var stopWords = map[string]bool{
+	"ca": true, "na": true, "hi": true, "tu": true, "api": true, "eva": true, "iti": true,
+	"va": true, "sa": true, "sah": true, "so": true, "te": true, "me": true, "tat": true,
+	"tad": true, "yat": true, "yad": true, "tam": true, "tvam": true, "aham": true,
+	"mam": true, "mama": true, "yah": true, "yo": true, "ye": true, "tatha": true,
+	"yatha": true, "tada": true, "yada": true, "tatra": true, "yatra": true, "atha": true,
+	"evam": true, "idam": true, "ayam": true, "asya": true, "tasya": true, "yasya": true,
+	"tena": true, "yena": true, "mayi": true, "kim": true, "uvaca": true,
+}
+
+// ChapterWords lists the most frequent significant words of a chapter
+type ChapterWords struct {
+	Chapter int         `json:"chapter"`
+	Name    string      `json:"name"`
+	Words   []FormCount `json:"words"`
+}
+
+// wordFrequencies returns, for each chapter of book, or only chapter when
+// not 0, its n most frequent words leaving out the stop words, in scheme
+func wordFrequencies(idx *wordIndex, book gita.Scripture, chapter, n int, scheme, lang string) []ChapterWords {
+	counts := make(map[int]map[string]int)
+	for word, postings := range idx.words {
+		if stopWords[word] || len(word) < 3 {
+			continue
+		}
+		for _, p := range postings {
+			number := idx.slokas[p.sloka].Chapter
+			if counts[number] == nil {
+				counts[number] = make(map[string]int)
+			}
+			form, _ := transliterate(p.form, scheme)
+			counts[number][form]++
+		}
+	}
+
+	var chapters []ChapterWords
+	for _, c := range book.Data().Chapters {
+		if chapter != 0 && c.ChapterNumber != chapter {
+			continue
+		}
+		words := sortedCounts(counts[c.ChapterNumber])
+		chapters = append(chapters, ChapterWords{
+			Chapter: c.ChapterNumber,
+			Name:    chapterName(c, lang),
+			Words:   words[:min(len(words), n)],
+		})
+	}
+	return chapters
+}
+
+// printWordFrequencies writes each chapter's words with their counts
+func printWordFrequencies(w io.Writer, chapters []ChapterWords) {
+	for _, c := range chapters {
+		fmt.Fprintf(w, "%s%2d  %s%s\n", Bold, c.Chapter, c.Name, Reset)
+		for _, f := range c.Words {
+			fmt.Fprintf(w, "    %s%s %4d\n", f.Form, strings.Repeat(" ", max(0, 24-textWidth(f.Form))), f.Count)
+		}
+	}
+}
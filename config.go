@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// configPath returns the path of the config file, honoring XDG_CONFIG_HOME.
+// config.toml is used when it exists, otherwise config.json.
+func configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = userConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	path := filepath.Join(dir, "gitasay", "config.toml")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return filepath.Join(dir, "gitasay", "config.json"), nil
+}
+
+// loadConfig reads the config file at path, mapping flag names to default
+// values. Files ending in .toml are parsed as TOML, anything else as a JSON
+// object. A missing file is not an error and yields no defaults.
+func loadConfig(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".toml" {
+		return parseTOML(data)
+	}
+	var config map[string]any
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// parseTOML parses the flat subset of TOML a config file needs: one
+// key = value pair per line with string, boolean or number values, and
+// # comments. Values have the same types encoding/json would produce.
+func parseTOML(data []byte) (map[string]any, error) {
+	config := make(map[string]any)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// find the closing quote, skipping escaped ones
+			end := -1
+			for j := 1; j < len(value); j++ {
+				if value[j] == '\\' {
+					j++
+				} else if value[j] == '"' {
+					end = j
+					break
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", i+1)
+			}
+			s, err := strconv.Unquote(value[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			config[key] = s
+			value = value[end+1:]
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", i+1)
+			}
+			config[key] = value[1 : end+1]
+			value = value[end+2:]
+		default:
+			value, _, _ = strings.Cut(value, "#")
+			value = strings.TrimSpace(value)
+			if value == "true" || value == "false" {
+				config[key] = value == "true"
+			} else if n, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64); err == nil {
+				config[key] = n
+			} else {
+				return nil, fmt.Errorf("line %d: invalid value %q", i+1, value)
+			}
+			value = ""
+		}
+		if rest := strings.TrimSpace(value); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", i+1, rest)
+		}
+	}
+	return config, nil
+}
+
+// configFlags are the flags a config file may set besides the displayFlags:
+// how verses are picked and the settings of the commands. Flags that do
+// something, such as serve, fav-add or hook-install, are only taken from the
+// command line, so a config file cannot turn every run into one.
+var configFlags = []string{
+	"chapters", "topic", "select", "n", "no-repeat", "daily-tz", "source", "api-url", "api-timeout",
+	"cache-ttl", "strict", "update-url", "search-in", "limit", "quiz-mode", "days", "per-day",
+	"feed-format", "feed-url", "bot-url", "bot-platform", "bot-chat", "bot-retries",
+	"sync-backend", "sync-url", "sync-token", "voice", "speak-rate", "speak-url", "audio-url",
+	"image-bg", "image-fg", "resolution", "wallpaper-image",
+	"host", "port", "protocol", "tls-cert", "tls-key", "every", "at",
+}
+
+// configurable reports whether a config file may set the flag called name
+func configurable(name string) bool {
+	switch name {
+	case "output", "o", "copy":
+		// these write a file or the clipboard on every run
+		return false
+	}
+	return slices.Contains(displayFlags, name) || slices.Contains(configFlags, name)
+}
+
+// applyConfig sets the flags of fs named in config, skipping those given on
+// the command line, under any of their names, so that they override the
+// config file
+func applyConfig(fs *flag.FlagSet, config map[string]any) error {
+	// aliases such as -o and -output share one value
+	explicit := make(map[flag.Value]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Value] = true })
+
+	for name, value := range config {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag %q", name)
+		}
+		if !configurable(name) {
+			return fmt.Errorf("flag %q can only be given on the command line", name)
+		}
+		if explicit[f.Value] {
+			continue
+		}
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case bool:
+			s = strconv.FormatBool(v)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Errorf("flag %q: value must be a string, number or boolean", name)
+		}
+		if err := fs.Set(name, s); err != nil {
+			return fmt.Errorf("flag %q: %v", name, err)
+		}
+	}
+	return nil
+}
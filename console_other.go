@@ -0,0 +1,44 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// setupConsole has nothing to prepare outside Windows, where terminals
+// draw ANSI styling and take UTF-8 as they are
+func setupConsole() bool {
+	return true
+}
+
+// userConfigDir returns the directory holding the gitasay config directory,
+// ~/.config
+func userConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// userStateDir returns the directory holding the gitasay state directory,
+// ~/.local/state
+func userStateDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+// userDataDir returns the directory holding the gitasay data directory,
+// ~/.local/share
+func userDataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
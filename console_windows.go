@@ -0,0 +1,72 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// cpUTF8 is the UTF-8 console code page
+const cpUTF8 = 65001
+
+// setupConsole switches the console to UTF-8, so the Devanagari is not
+// printed as question marks, and turns on virtual terminal processing for
+// stdout and stderr so the ANSI styling is drawn rather than printed. It
+// reports whether the styling works, false on consoles older than Windows 10.
+func setupConsole() bool {
+	windows.SetConsoleOutputCP(cpUTF8)
+	windows.SetConsoleCP(cpUTF8)
+	ok := true
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		h := windows.Handle(f.Fd())
+		var mode uint32
+		if windows.GetConsoleMode(h, &mode) != nil {
+			continue // redirected, not a console
+		}
+		if windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) != nil && f == os.Stdout {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// platformDir returns the gitasay directory under base, or under the home
+// directory's Unix-style legacy path when that is where the files already are
+func platformDir(base func() (string, error), legacy ...string) (string, error) {
+	if home, err := os.UserHomeDir(); err == nil {
+		dir := filepath.Join(append([]string{home}, legacy...)...)
+		if _, err := os.Stat(filepath.Join(dir, "gitasay")); err == nil {
+			return dir, nil
+		}
+	}
+	return base()
+}
+
+// userConfigDir returns the directory holding the gitasay config directory,
+// %AppData%
+func userConfigDir() (string, error) {
+	return platformDir(os.UserConfigDir, ".config")
+}
+
+// localAppData returns %LocalAppData%
+func localAppData() (string, error) {
+	if dir := os.Getenv("LocalAppData"); dir != "" {
+		return dir, nil
+	}
+	return os.UserCacheDir()
+}
+
+// userStateDir returns the directory holding the gitasay state directory,
+// %LocalAppData%
+func userStateDir() (string, error) {
+	return platformDir(localAppData, ".local", "state")
+}
+
+// userDataDir returns the directory holding the gitasay data directory,
+// %LocalAppData%
+func userDataDir() (string, error) {
+	return platformDir(localAppData, ".local", "share")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// bubbleMargin is the width taken by the bubble border and padding
+const bubbleMargin = 4
+
+// figures holds the ASCII art that -figure can draw below the bubble
+var figures = map[string]string{
+	"chariot": `      \
+       \        |>
+        \       |
+         \   __/|\__
+            /  ( )  \        ,
+    __/\___/___/ \___\______/|
+   (  ___      ___      ___  )
+    \/ \_/    / O \    \_/ \/
+               \_/
+`,
+	"cow": `        \   ^__^
+         \  (oo)\_______
+            (__)\       )\/\
+                ||----w |
+                ||     ||
+`,
+	"tux": `   \
+    \
+        .--.
+       |o_o |
+       |:_/ |
+      //   \ \
+     (|     | )
+    /'\_   _/` + "`" + `\
+    \___)=(___/
+`,
+	"lotus": `        \
+         \      _
+          \   _( )_
+           _ ( (o) ) _
+          ( )_(_)_( )
+         (_(_(_)_)_)_)
+             \ | /
+          ~~~~~~~~~~~~~~
+`,
+}
+
+// figureNames returns the available figure names in sorted order
+func figureNames() []string {
+	names := make([]string, 0, len(figures))
+	for name := range figures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// speechBubble draws a cowsay-style bubble around text followed by figure.
+// Widths are measured without ANSI escapes so styled lines align too.
+func speechBubble(text, figure string) string {
+	lines := strings.Split(strings.Trim(text, "\n"), "\n")
+	width := 0
+	for _, line := range lines {
+		if w := visibleWidth(line); w > width {
+			width = w
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(" " + strings.Repeat("_", width+2) + "\n")
+	for i, line := range lines {
+		left, right := "|", "|"
+		switch {
+		case len(lines) == 1:
+			left, right = "<", ">"
+		case i == 0:
+			left, right = "/", "\\"
+		case i == len(lines)-1:
+			left, right = "\\", "/"
+		}
+		pad := strings.Repeat(" ", width-visibleWidth(line))
+		b.WriteString(left + " " + line + pad + " " + right + "\n")
+	}
+	b.WriteString(" " + strings.Repeat("-", width+2) + "\n")
+	b.WriteString(figure)
+	return b.String()
+}
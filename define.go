@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// TermJSON is a glossary entry with the verses it occurs in
+type TermJSON struct {
+	gita.Term
+	Verses []string `json:"verses"` // references such as "2.47"
+}
+
+// termJSON returns t with the references of the slokas it occurs in
+func termJSON(t gita.Term, occurrences []Sloka) TermJSON {
+	refs := make([]string, len(occurrences))
+	for i, s := range occurrences {
+		refs[i] = fmt.Sprintf("%d.%d", s.Chapter, s.Verse)
+	}
+	return TermJSON{Term: t, Verses: refs}
+}
+
+// printTerm writes the entry: the term in IAST and Devanagari, its meaning,
+// the related terms to look up next and the verses it occurs in
+func (r renderer) printTerm(w io.Writer, t TermJSON) {
+	fmt.Fprintf(w, "\n%s%s%s  %s\n\n", Bold, t.IAST, Reset, t.Devanagari)
+	fmt.Fprintln(w, r.wrap(t.Meaning))
+	if len(t.See) > 0 {
+		fmt.Fprintf(w, "\n%sSee also:%s %s\n", Bold, Reset, strings.Join(t.See, ", "))
+	}
+	fmt.Fprintf(w, "\n%sVerses (%d):%s\n", Bold, len(t.Verses), Reset)
+	fmt.Fprintln(w, r.wrap(strings.Join(t.Verses, " ")))
+}
+
+// suggestTerms returns the glossary terms that start like word, for a word
+// the glossary lacks
+func suggestTerms(word string) []string {
+	word = strings.ToLower(word)
+	var names []string
+	for _, name := range gita.Terms() {
+		if len(word) >= 3 && strings.HasPrefix(name, word[:3]) || strings.Contains(name, word) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
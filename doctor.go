@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// severities of the problems doctor finds: errors break gitasay, warnings
+// only leave something out
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// maxRefs caps the verses listed in one problem
+const maxRefs = 5
+
+// DoctorProblem is one thing wrong with a dataset
+type DoctorProblem struct {
+	Severity string `json:"severity"`
+	Where    string `json:"where"` // e.g. "chapter 2", "2.47" or "line 12"
+	Message  string `json:"message"`
+}
+
+// TranslationCount is how many verses of a dataset one translation covers
+type TranslationCount struct {
+	Key     string `json:"key"`
+	Verses  int    `json:"verses"`
+	Missing int    `json:"missing"`
+}
+
+// DoctorReport is what doctor finds in a dataset
+type DoctorReport struct {
+	Dataset      string             `json:"dataset"` // the file, or the embedded text's name
+	Chapters     int                `json:"chapters"`
+	Verses       int                `json:"verses"`
+	Translations []TranslationCount `json:"translations"`
+	Errors       int                `json:"errors"`
+	Warnings     int                `json:"warnings"`
+	Problems     []DoctorProblem    `json:"problems"`
+}
+
+// add records a problem
+func (d *DoctorReport) add(severity, where, format string, args ...any) {
+	d.Problems = append(d.Problems, DoctorProblem{Severity: severity, Where: where, Message: fmt.Sprintf(format, args...)})
+	if severity == SeverityError {
+		d.Errors++
+	} else {
+		d.Warnings++
+	}
+}
+
+// refList joins refs, naming only the first maxRefs
+func refList(refs []string) string {
+	if len(refs) <= maxRefs {
+		return strings.Join(refs, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(refs[:maxRefs], ", "), len(refs)-maxRefs)
+}
+
+// checkDatasetFile checks the dataset file data, reporting invalid UTF-8 and
+// JSON as well as what checkDataset finds
+func checkDatasetFile(name string, data []byte) DoctorReport {
+	report := DoctorReport{Dataset: name, Translations: []TranslationCount{}, Problems: []DoctorProblem{}}
+	if !utf8.Valid(data) {
+		for i, line := range bytes.Split(data, []byte("\n")) {
+			if !utf8.Valid(line) {
+				report.add(SeverityError, fmt.Sprintf("line %d", i+1), "invalid UTF-8")
+			}
+		}
+	}
+	var all gita.AllSlokas
+	if err := json.Unmarshal(data, &all); err != nil {
+		// Parse says where in the file the JSON breaks
+		if _, perr := gita.Parse(data); perr != nil {
+			err = perr
+		}
+		report.add(SeverityError, name, "invalid JSON: %v", err)
+		return report
+	}
+	checkDataset(&report, all)
+	return report
+}
+
+// checkDataset checks all for what the loader rejects, as Parse does, and
+// for what it lets through but shows wrong: chapters whose verses_count
+// disagrees with their verses, verses without Sanskrit, gaps in the verse
+// numbers and translations missing from some verses
+func checkDataset(report *DoctorReport, all gita.AllSlokas) {
+	report.Chapters, report.Verses = len(all.Chapters), len(all.Slokas)
+	if len(all.Slokas) == 0 {
+		report.add(SeverityError, "slokas", "no verses")
+	}
+
+	chapters := make(map[int]gita.Chapter)
+	for i, c := range all.Chapters {
+		where := fmt.Sprintf("chapter %d", c.ChapterNumber)
+		switch {
+		case c.ChapterNumber < 1:
+			report.add(SeverityError, fmt.Sprintf("chapters[%d]", i), "chapter_number must be positive")
+			continue
+		case chapters[c.ChapterNumber].ChapterNumber != 0:
+			report.add(SeverityError, where, "appears twice")
+			continue
+		}
+		chapters[c.ChapterNumber] = c
+		if strings.TrimSpace(c.Name) == "" {
+			report.add(SeverityWarning, where, "no name")
+		}
+		checkStrings(report, where, chapterStrings(c))
+	}
+
+	seen := make(map[[2]int]bool)
+	ids := make(map[string]string)
+	verses := make(map[int][]int)
+	for i, s := range all.Slokas {
+		ref := fmt.Sprintf("%d.%d", s.Chapter, s.Verse)
+		switch {
+		case s.Chapter < 1 || s.Verse < 1:
+			report.add(SeverityError, fmt.Sprintf("slokas[%d]", i), "chapter and verse must be positive")
+			continue
+		case seen[[2]int{s.Chapter, s.Verse}]:
+			report.add(SeverityError, ref, "appears twice")
+			continue
+		}
+		seen[[2]int{s.Chapter, s.Verse}] = true
+		verses[s.Chapter] = append(verses[s.Chapter], s.Verse)
+		if _, ok := chapters[s.Chapter]; !ok {
+			report.add(SeverityError, ref, "chapter %d is not in the chapters", s.Chapter)
+		}
+		switch other, dup := ids[s.ID]; {
+		case s.ID == "":
+			report.add(SeverityWarning, ref, "no _id, so it cannot be a favorite or have notes")
+		case dup:
+			report.add(SeverityError, ref, "_id %s is also the id of %s", s.ID, other)
+		default:
+			ids[s.ID] = ref
+		}
+		if strings.TrimSpace(s.Slok) == "" {
+			report.add(SeverityError, ref, "no Sanskrit text")
+		}
+		if strings.TrimSpace(s.Transliteration) == "" {
+			report.add(SeverityWarning, ref, "no transliteration")
+		}
+		checkStrings(report, ref, slokaStrings(s))
+	}
+
+	numbers := make([]int, 0, len(chapters))
+	for n := range chapters {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+	for _, n := range numbers {
+		where := fmt.Sprintf("chapter %d", n)
+		if got := len(verses[n]); got != chapters[n].VersesCount {
+			report.add(SeverityError, where, "verses_count is %d but it has %d verses", chapters[n].VersesCount, got)
+		}
+		slices.Sort(verses[n])
+		var gaps []string
+		prev := 0
+		for _, v := range verses[n] {
+			if gap := fmt.Sprintf("%d.%d", n, prev+1); v == prev+2 {
+				gaps = append(gaps, gap)
+			} else if v > prev+2 {
+				gaps = append(gaps, fmt.Sprintf("%s-%d", gap, v-1))
+			}
+			prev = v
+		}
+		if len(gaps) > 0 {
+			report.add(SeverityWarning, where, "missing verses %s", refList(gaps))
+		}
+	}
+
+	// a translation any verse has is offered for every verse, so each one
+	// without it is an error; one no verse has is only left out
+	for _, t := range translators {
+		var missing []string
+		for _, s := range all.Slokas {
+			if _, _, ok := resolveTranslation(s, t.Key); !ok {
+				missing = append(missing, fmt.Sprintf("%d.%d", s.Chapter, s.Verse))
+			}
+		}
+		tc := TranslationCount{Key: t.Key, Verses: len(all.Slokas) - len(missing), Missing: len(missing)}
+		report.Translations = append(report.Translations, tc)
+		switch {
+		case len(missing) == 0:
+		case tc.Verses == 0:
+			report.add(SeverityWarning, t.Key, "no verses have this translation")
+		default:
+			report.add(SeverityError, t.Key, "no translation in %s", refList(missing))
+		}
+	}
+}
+
+// checkStrings reports the fields, pairs of name and text, that are not
+// valid UTF-8 or hold U+FFFD, which is what JSON decoding leaves of bytes
+// that were not
+func checkStrings(report *DoctorReport, where string, fields [][2]string) {
+	for _, f := range fields {
+		if !utf8.ValidString(f[1]) || strings.ContainsRune(f[1], utf8.RuneError) {
+			report.add(SeverityError, where, "%s has invalid UTF-8", f[0])
+		}
+	}
+}
+
+// chapterStrings returns the text fields of c for checkStrings
+func chapterStrings(c gita.Chapter) [][2]string {
+	return [][2]string{
+		{"name", c.Name}, {"translation", c.Translation}, {"transliteration", c.Transliteration},
+		{"meaning.en", c.Meaning.En}, {"meaning.hi", c.Meaning.Hi},
+		{"summary.en", c.Summary.En}, {"summary.hi", c.Summary.Hi},
+	}
+}
+
+// slokaStrings returns the text fields of s for checkStrings
+func slokaStrings(s Sloka) [][2]string {
+	return [][2]string{
+		{"slok", s.Slok}, {"transliteration", s.Transliteration}, {"padapatha", s.Padapatha},
+		{"tej.ht", s.Tej.Ht}, {"siva.et", s.Siva.Et}, {"siva.ec", s.Siva.Ec},
+		{"purohit.et", s.Purohit.Et}, {"chinmay.hc", s.Chinmay.Hc},
+		{"san.et", s.San.Et}, {"adi.et", s.Adi.Et},
+	}
+}
+
+// printDoctorReport writes the counts, the translations and the problems
+// of report, ending with the verdict
+func printDoctorReport(w io.Writer, report DoctorReport) {
+	fmt.Fprintf(w, "%sDataset:%s %s\n", Bold, Reset, report.Dataset)
+	fmt.Fprintf(w, "%sChapters:%s %d\n%sVerses:%s %d\n", Bold, Reset, report.Chapters, Bold, Reset, report.Verses)
+	if len(report.Translations) > 0 {
+		fmt.Fprintf(w, "%sTranslations:%s\n", Bold, Reset)
+		for _, t := range report.Translations {
+			fmt.Fprintf(w, "  %-8s %4d verses", t.Key, t.Verses)
+			if t.Missing > 0 {
+				fmt.Fprintf(w, ", %d missing", t.Missing)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	if len(report.Problems) > 0 {
+		fmt.Fprintf(w, "%sProblems:%s\n", Bold, Reset)
+		for _, p := range report.Problems {
+			style := Dim
+			if p.Severity == SeverityError {
+				style = Bold
+			}
+			fmt.Fprintf(w, "  %s%-7s%s  %-10s  %s\n", style, p.Severity, Reset, p.Where, p.Message)
+		}
+	}
+	if report.Errors == 0 && report.Warnings == 0 {
+		fmt.Fprintln(w, "No problems found.")
+		return
+	}
+	fmt.Fprintf(w, "%s, %s.\n", count(report.Errors, "error"), count(report.Warnings, "warning"))
+}
+
+// count returns n with the noun, made plural unless n is 1, e.g. "2 errors"
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// epubMimetype is the first file of every EPUB, stored uncompressed so
+// readers can recognize the archive from its first bytes
+const epubMimetype = "application/epub+zip"
+
+// epubContainer points readers at the package document
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+// epubChapter is one chapter of the book as a file of the EPUB
+type epubChapter struct {
+	file, title string
+	slokas      []Sloka
+}
+
+// exportEPUB writes slokas as an EPUB 3 book: a page per chapter with its
+// heading and verses as in the HTML export, and a table of contents of the
+// chapters for the reader's navigation, also as an NCX for older readers
+func (r renderer) exportEPUB(w io.Writer, book gita.Scripture, slokas []Sloka) error {
+	title := book.Info().Title
+	var chapters []epubChapter
+	for _, s := range slokas {
+		if len(chapters) == 0 || chapters[len(chapters)-1].slokas[0].Chapter != s.Chapter {
+			name := fmt.Sprintf("%s %d", label("Chapter", r.lang), s.Chapter)
+			if chapter, ok := book.Chapter(s.Chapter); ok {
+				name += ": " + firstNonEmpty(chapter.Name, chapterName(chapter, r.lang))
+			}
+			chapters = append(chapters, epubChapter{file: fmt.Sprintf("chapter-%d.xhtml", s.Chapter), title: name})
+		}
+		chapters[len(chapters)-1].slokas = append(chapters[len(chapters)-1].slokas, s)
+	}
+	if len(chapters) == 1 {
+		title = fmt.Sprintf("%s, %s %d", title, label("Chapter", r.lang), chapters[0].slokas[0].Chapter)
+	}
+	id := fmt.Sprintf("urn:gitasay:%s:%s", strings.ToLower(book.Info().Abbrev), r.source)
+	modified := time.Now().UTC().Truncate(time.Second)
+
+	z := zip.NewWriter(w)
+	// the mimetype may have neither compression nor a data descriptor, so
+	// it is written raw with its checksum and size up front
+	header := &zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE([]byte(epubMimetype)),
+		CompressedSize64:   uint64(len(epubMimetype)),
+		UncompressedSize64: uint64(len(epubMimetype)),
+	}
+	// raw headers take only the MS-DOS time fields
+	header.SetModTime(modified)
+	mimetype, err := z.CreateRaw(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, epubMimetype); err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"META-INF/container.xml": epubContainer,
+		"OEBPS/content.opf":      r.epubPackage(id, title, modified, chapters),
+		"OEBPS/nav.xhtml":        r.epubNav(title, chapters),
+		"OEBPS/toc.ncx":          epubNCX(id, title, chapters),
+		"OEBPS/style.css":        htmlStyle,
+	}
+	names := []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/style.css"}
+	for _, c := range chapters {
+		name := "OEBPS/" + c.file
+		files[name] = r.epubPage(book, c)
+		names = append(names, name)
+	}
+	for _, name := range names {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, files[name]); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// epubPackage returns the package document: the book's metadata, its files
+// and the reading order of the chapters
+func (r renderer) epubPackage(id, title string, modified time.Time, chapters []epubChapter) string {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	b.WriteString("<package xmlns=\"http://www.idpf.org/2007/opf\" version=\"3.0\" unique-identifier=\"book-id\">\n")
+	b.WriteString("<metadata xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	fmt.Fprintf(&b, "<dc:identifier id=\"book-id\">%s</dc:identifier>\n", html.EscapeString(id))
+	fmt.Fprintf(&b, "<dc:title>%s</dc:title>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<dc:language>%s</dc:language>\n", r.lang)
+	if len(chapters) > 0 {
+		if _, author := r.resolve(chapters[0].slokas[0]).translation(chapters[0].slokas[0]); author != "" {
+			fmt.Fprintf(&b, "<dc:contributor>%s</dc:contributor>\n", html.EscapeString(author))
+		}
+	}
+	fmt.Fprintf(&b, "<meta property=\"dcterms:modified\">%s</meta>\n", modified.Format("2006-01-02T15:04:05Z"))
+	b.WriteString("</metadata>\n<manifest>\n")
+	b.WriteString("<item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	b.WriteString("<item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n")
+	b.WriteString("<item id=\"style\" href=\"style.css\" media-type=\"text/css\"/>\n")
+	for i, c := range chapters {
+		fmt.Fprintf(&b, "<item id=\"c%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, c.file)
+	}
+	b.WriteString("</manifest>\n<spine toc=\"ncx\">\n<itemref idref=\"nav\"/>\n")
+	for i := range chapters {
+		fmt.Fprintf(&b, "<itemref idref=\"c%d\"/>\n", i+1)
+	}
+	b.WriteString("</spine>\n</package>\n")
+	return b.String()
+}
+
+// epubDocument wraps body in an XHTML page titled title
+func (r renderer) epubDocument(title, body string) string {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE html>\n")
+	fmt.Fprintf(&b, "<html xmlns=\"http://www.w3.org/1999/xhtml\" xmlns:epub=\"http://www.idpf.org/2007/ops\" lang=\"%s\" xml:lang=\"%s\">\n", r.lang, r.lang)
+	fmt.Fprintf(&b, "<head>\n<meta charset=\"utf-8\"/>\n<title>%s</title>\n", html.EscapeString(title))
+	b.WriteString("<link rel=\"stylesheet\" type=\"text/css\" href=\"style.css\"/>\n</head>\n<body>\n")
+	b.WriteString(body)
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// epubNav returns the table of contents page listing the chapters
+func (r renderer) epubNav(title string, chapters []epubChapter) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<nav epub:type=\"toc\" id=\"toc\">\n<ol>\n", html.EscapeString(title))
+	for _, c := range chapters {
+		fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>\n", c.file, html.EscapeString(c.title))
+	}
+	b.WriteString("</ol>\n</nav>\n")
+	return r.epubDocument(title, b.String())
+}
+
+// epubNCX returns the EPUB 2 table of contents, which some e-readers still
+// navigate by
+func epubNCX(id, title string, chapters []epubChapter) string {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<ncx xmlns=\"http://www.daisy.org/z3986/2005/ncx/\" version=\"2005-1\">\n")
+	fmt.Fprintf(&b, "<head>\n<meta name=\"dtb:uid\" content=\"%s\"/>\n</head>\n", html.EscapeString(id))
+	fmt.Fprintf(&b, "<docTitle><text>%s</text></docTitle>\n<navMap>\n", html.EscapeString(title))
+	for i, c := range chapters {
+		fmt.Fprintf(&b, "<navPoint id=\"c%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, html.EscapeString(c.title), c.file)
+	}
+	b.WriteString("</navMap>\n</ncx>\n")
+	return b.String()
+}
+
+// epubPage returns the page of chapter c: its heading and verses written as
+// by the HTML export, with the line breaks closed as XHTML needs
+func (r renderer) epubPage(book gita.Scripture, c epubChapter) string {
+	var body bytes.Buffer
+	if chapter, ok := book.Chapter(c.slokas[0].Chapter); ok {
+		r.htmlChapter(&body, chapter)
+	}
+	for _, s := range c.slokas {
+		r.html(&body, s)
+	}
+	return r.epubDocument(c.title, strings.ReplaceAll(body.String(), "<br>", "<br/>"))
+}
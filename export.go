@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// exportFormats lists the formats accepted by -export
+var exportFormats = []string{"txt", "json", "md", "html", "epub", "fortune"}
+
+// export writes every verse of the ordered slokas to w, one verse at a
+// time so large exports are streamed rather than built up in memory. Text,
+// Markdown and HTML exports start each chapter with its heading, HTML
+// exports being a whole document; JSON exports are a single array of verse
+// objects, and EPUB exports a book of HTML chapters.
+func (r renderer) export(w io.Writer, format string, book gita.Scripture, slokas []Sloka, allTranslations bool) error {
+	switch format {
+	case "json":
+		return r.exportJSON(w, slokas, allTranslations)
+	case "epub":
+		return r.exportEPUB(w, book, slokas)
+	}
+
+	switch format {
+	case "txt":
+		fmt.Fprintln(w)
+	case "html":
+		title := book.Info().Title
+		if len(slokas) > 0 && slokas[0].Chapter == slokas[len(slokas)-1].Chapter {
+			title = fmt.Sprintf("%s, %s %d", title, label("Chapter", r.lang), slokas[0].Chapter)
+		}
+		htmlHeader(w, title, r.lang)
+	}
+	current := 0
+	for _, sloka := range slokas {
+		// start a new chapter section
+		if sloka.Chapter != current {
+			current = sloka.Chapter
+			if chapter, ok := book.Chapter(current); ok {
+				switch format {
+				case "md":
+					r.markdownChapter(w, chapter)
+				case "html":
+					r.htmlChapter(w, chapter)
+				default:
+					r.chapterInfo(w, chapter)
+				}
+			}
+		}
+		switch format {
+		case "md":
+			r.markdown(w, sloka)
+		case "html":
+			r.html(w, sloka)
+		default:
+			r.sloka(w, sloka)
+		}
+	}
+	if format == "html" {
+		htmlFooter(w)
+	}
+	return nil
+}
+
+// exportJSON writes slokas as an indented JSON array, encoding each verse
+// as it goes
+func (r renderer) exportJSON(w io.Writer, slokas []Sloka, allTranslations bool) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, sloka := range slokas {
+		data, err := json.MarshalIndent(r.verseJSON(sloka, allTranslations), "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if i == 0 {
+			sep = "\n  "
+		}
+		if _, err := fmt.Fprintf(w, "%s%s", sep, data); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+// fortuneEntries returns one fortune(6) cookie per sloka: the wrapped
+// translation of the active source followed by its attribution
+func (r renderer) fortuneEntries(slokas []Sloka) []string {
+	entries := make([]string, 0, len(slokas))
+	for _, s := range slokas {
+		c := r.resolve(s)
+		text, author := c.translation(s)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s\n\t-- %s %d.%d (%s)\n",
+			c.wrap(cleanTranslation(text)), r.text.Title, s.Chapter, s.Verse, author))
+	}
+	return entries
+}
+
+// exportFortune writes slokas as a fortune file, the cookies separated by
+// lines holding a single "%", and returns the cookies written
+func (r renderer) exportFortune(w io.Writer, slokas []Sloka) ([]string, error) {
+	entries := r.fortuneEntries(slokas)
+	for _, e := range entries {
+		if _, err := io.WriteString(w, e+"%\n"); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// strfileVersion is the version of the strfile(8) index format written
+const strfileVersion = 2
+
+// writeStrfile writes the strfile(8) index of a fortune file holding entries
+// to path, so fortune can pick cookies without reading the whole file: a
+// header of big-endian counts and lengths, then the offset of every cookie
+// and the end of the file
+func writeStrfile(path string, entries []string) error {
+	var longest, shortest uint32
+	offsets := make([]uint32, 0, len(entries)+1)
+	offset := uint32(0)
+	for i, e := range entries {
+		offsets = append(offsets, offset)
+		n := uint32(len(e))
+		longest = max(longest, n)
+		if i == 0 || n < shortest {
+			shortest = n
+		}
+		offset += n + uint32(len("%\n"))
+	}
+	offsets = append(offsets, offset)
+
+	var b bytes.Buffer
+	for _, v := range []uint32{strfileVersion, uint32(len(entries)), longest, shortest, 0} {
+		binary.Write(&b, binary.BigEndian, v)
+	}
+	b.Write([]byte{'%', 0, 0, 0})
+	binary.Write(&b, binary.BigEndian, offsets)
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// errNoVerse reports a well-formed reference to a verse the dataset lacks
+var errNoVerse = errors.New("verse not found")
+
+// favoritesPath returns the file holding the bookmarked sloka ids
+func favoritesPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "favorites.json"), nil
+}
+
+// loadFavorites returns the bookmarked sloka ids in the order they were added.
+// A missing file means no favorites.
+func loadFavorites() ([]string, error) {
+	path, err := favoritesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return ids, nil
+}
+
+// saveFavorites replaces the bookmarked sloka ids with ids
+func saveFavorites(ids []string) error {
+	path, err := favoritesPath()
+	if err != nil {
+		return err
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return writeState(path, ids)
+}
+
+// favoriteSlokas returns the slokas of ids, skipping ids the dataset lacks
+func favoriteSlokas(book gita.Scripture, ids []string) []Sloka {
+	var slokas []Sloka
+	for _, id := range ids {
+		if s, ok := book.ByID(id); ok {
+			slokas = append(slokas, s)
+		}
+	}
+	return slokas
+}
+
+// printFavorites writes one line per favorite: the bold reference followed
+// by the start of its translation
+func printFavorites(w io.Writer, slokas []Sloka, r renderer) {
+	for _, s := range slokas {
+		ref := fmt.Sprintf("%d.%d", s.Chapter, s.Verse)
+		text, _ := r.resolve(s).translation(s)
+		width := r.width - len(r.text.Abbrev) - 1 - len(ref) - 2
+		fmt.Fprintf(w, "%s%s %s%s  %s\n", Bold, r.text.Abbrev, ref, Reset, truncate(cleanTranslation(text), width))
+	}
+}
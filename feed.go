@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// feedFormats lists the formats accepted by -feed-format, the default first
+var feedFormats = []string{"atom", "rss"}
+
+// feedEntry is the verse of one day in a feed
+type feedEntry struct {
+	day   time.Time
+	sloka Sloka
+}
+
+// dailyEntries returns the verse of the day, as -daily picks it from pool,
+// for each of the days days up to today, newest first
+func dailyEntries(pool []Sloka, today time.Time, days int) []feedEntry {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	entries := make([]feedEntry, days)
+	for i := range entries {
+		day := today.AddDate(0, 0, -i)
+		entries[i] = feedEntry{day, pool[periodIndex(dayKey(day), len(pool))]}
+	}
+	return entries
+}
+
+// atomFeed and the types below it are the parts of an Atom feed gitasay
+// writes
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// rssFeed and the types below it are the parts of an RSS 2.0 feed gitasay
+// writes
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// writeFeed writes entries as an Atom or RSS feed. Each entry holds the
+// verse as HTML, its Sanskrit, transliteration and translation, and is dated
+// the start of its day. link is the address the feed is published at, used
+// for the links and ids when given.
+func (r renderer) writeFeed(w io.Writer, format string, entries []feedEntry, link string) error {
+	title := fmt.Sprintf("%s: verse of the day", r.text.Title)
+	entryID := func(e feedEntry) string {
+		if link != "" {
+			return link + "#" + dayKey(e.day)
+		}
+		return fmt.Sprintf("urn:gitasay:%s:%s", r.text.Name, dayKey(e.day))
+	}
+	entryTitle := func(e feedEntry) string {
+		return fmt.Sprintf("%s: %s %d.%d", dayKey(e.day), r.text.Abbrev, e.sloka.Chapter, e.sloka.Verse)
+	}
+	content := func(e feedEntry) string {
+		var b strings.Builder
+		r.html(&b, e.sloka)
+		return b.String()
+	}
+
+	var feed any
+	switch format {
+	case "rss":
+		channel := rssChannel{Title: title, Link: link, Description: title}
+		for _, e := range entries {
+			item := rssItem{
+				Title:       entryTitle(e),
+				GUID:        rssGUID{IsPermaLink: link != "", ID: entryID(e)},
+				PubDate:     e.day.Format(time.RFC1123Z),
+				Description: content(e),
+			}
+			if link != "" {
+				item.Link = entryID(e)
+			}
+			channel.Items = append(channel.Items, item)
+		}
+		feed = rssFeed{Version: "2.0", Channel: channel}
+	default:
+		atom := atomFeed{Title: title, ID: "urn:gitasay:" + r.text.Name, Author: atomAuthor{"gitasay"}}
+		if link != "" {
+			atom.ID = link
+			atom.Link = &atomLink{Href: link, Rel: "self"}
+		}
+		if len(entries) > 0 {
+			atom.Updated = entries[0].day.Format(time.RFC3339)
+		}
+		for _, e := range entries {
+			entry := atomEntry{
+				Title:   entryTitle(e),
+				ID:      entryID(e),
+				Updated: e.day.Format(time.RFC3339),
+				Content: atomContent{Type: "html", Body: content(e)},
+			}
+			if link != "" {
+				entry.Link = &atomLink{Href: entryID(e)}
+			}
+			atom.Entries = append(atom.Entries, entry)
+		}
+		feed = atom
+	}
+
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
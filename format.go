@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// verse output formats accepted by -format; auto is text, styled only on a
+// terminal, and wrapped at the fallback width for pipes and files
+const (
+	FormatAuto     = "auto"
+	FormatText     = "text"
+	FormatPlain    = "plain"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatMarkdown = "md"
+)
+
+// outputFormats lists the -format values
+var outputFormats = []string{FormatAuto, FormatText, FormatPlain, FormatJSON, FormatYAML, FormatMarkdown}
+
+// verseFormat writes the verses picked for display in one output format
+type verseFormat interface {
+	writeVerses(w io.Writer, slokas []Sloka) error
+}
+
+// ttyFormat writes the verses as shown on a terminal, laid out by render
+// between the -prefix and -suffix
+type ttyFormat struct {
+	opts           Options
+	book           gita.Scripture
+	prefix, suffix string
+	styled         bool // keep ANSI escapes, else strip them from the data too
+}
+
+func (f ttyFormat) writeVerses(w io.Writer, slokas []Sloka) error {
+	var out strings.Builder
+	render(&out, f.book, slokas, f.opts)
+	text := unescape(f.prefix) + out.String() + unescape(f.suffix)
+	if !f.styled {
+		// escapes in -prefix, -suffix or the data are not wanted either,
+		// nor their spelled-out forms such as \033[1m
+		text = ansiEscape.ReplaceAllString(text, "")
+		text = spelledEscape.ReplaceAllString(text, "")
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// plainFormat writes the verses for other programs to read: unwrapped,
+// unstyled and with single blank lines between the blocks
+type plainFormat struct {
+	ttyFormat
+}
+
+func (f plainFormat) writeVerses(w io.Writer, slokas []Sloka) error {
+	f.opts.wrapper, f.styled = unwrapped, false
+	var out strings.Builder
+	f.ttyFormat.writeVerses(&out, slokas)
+	_, err := io.WriteString(w, plainText(out.String()))
+	return err
+}
+
+// jsonFormat writes the verses as JSON, or YAML with the same fields: one
+// object, or an array of several
+type jsonFormat struct {
+	r               renderer
+	book            gita.Scripture
+	allTranslations bool
+	chapterInfo     bool // add each verse's chapter
+	yaml            bool
+}
+
+func (f jsonFormat) writeVerses(w io.Writer, slokas []Sloka) error {
+	var verses []VerseJSON
+	for _, sloka := range slokas {
+		v := f.r.verseJSON(sloka, f.allTranslations)
+		if f.chapterInfo {
+			if chapter, ok := f.book.Chapter(sloka.Chapter); ok {
+				v.ChapterInfo = &chapter
+			}
+		}
+		verses = append(verses, v)
+	}
+	var v any = verses
+	if len(verses) == 1 {
+		v = verses[0]
+	}
+	if f.yaml {
+		return writeYAML(w, v)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// markdownFormat writes the verses as Markdown, each run of a chapter's
+// verses under its heading when chapterInfo is set
+type markdownFormat struct {
+	r           renderer
+	book        gita.Scripture
+	chapterInfo bool
+}
+
+func (f markdownFormat) writeVerses(w io.Writer, slokas []Sloka) error {
+	for i, sloka := range slokas {
+		if f.chapterInfo && (i == 0 || slokas[i-1].Chapter != sloka.Chapter) {
+			if chapter, ok := f.book.Chapter(sloka.Chapter); ok {
+				f.r.markdownChapter(w, chapter)
+			}
+		}
+		f.r.markdown(w, sloka)
+	}
+	return nil
+}
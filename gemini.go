@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// protocols -serve speaks
+const (
+	ProtocolHTTP   = "http"
+	ProtocolGemini = "gemini"
+)
+
+// serveProtocols lists the -protocol values
+var serveProtocols = []string{ProtocolHTTP, ProtocolGemini}
+
+const (
+	geminiPort       = 1965             // default port of -protocol gemini
+	geminiTimeout    = 10 * time.Second // for reading a request and writing its response
+	maxGeminiRequest = 1024             // longest request URL the protocol allows
+)
+
+// Gemini status codes used by the capsule
+const (
+	geminiSuccess    = 20
+	geminiNotFound   = 51
+	geminiBadRequest = 59
+)
+
+// geminiCertificate returns the TLS certificate of the capsule: the one in
+// the certFile and keyFile if given, else a self-signed one for host that
+// is created on first use and kept in the state directory, so clients that
+// trust it on first use keep recognizing the capsule
+func geminiCertificate(certFile, keyFile, host string) (tls.Certificate, error) {
+	if certFile != "" || keyFile != "" {
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+	dir, err := stateDir()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	certFile, keyFile = filepath.Join(dir, "gemini-cert.pem"), filepath.Join(dir, "gemini-key.pem")
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		return cert, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return tls.Certificate{}, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+// serveGemini answers Gemini requests on addr until listening fails
+func (sv *server) serveGemini(addr string, cert tls.Certificate) error {
+	ln, err := tls.Listen("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go sv.handleGemini(conn)
+	}
+}
+
+// handleGemini answers the one request a Gemini connection carries: an
+// absolute URL ended by CRLF
+func (sv *server) handleGemini(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(geminiTimeout))
+	line, err := bufio.NewReader(io.LimitReader(conn, maxGeminiRequest+2)).ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(conn, "%d request must be a URL of at most %d bytes ended by CRLF\r\n", geminiBadRequest, maxGeminiRequest)
+		return
+	}
+	u, err := url.Parse(strings.TrimRight(line, "\r\n"))
+	if err != nil || (u.Scheme != "" && u.Scheme != ProtocolGemini) {
+		fmt.Fprintf(conn, "%d not a gemini URL\r\n", geminiBadRequest)
+		return
+	}
+	status, body := sv.gemini(u.Path)
+	if status != geminiSuccess {
+		fmt.Fprintf(conn, "%d %s\r\n", status, body)
+		return
+	}
+	fmt.Fprintf(conn, "%d text/gemini; charset=utf-8; lang=%s\r\n%s", status, sv.r.lang, body)
+}
+
+// gemini returns the status and gemtext page of path, or the error message
+// for the status line when it is not found
+func (sv *server) gemini(path string) (int, string) {
+	var b strings.Builder
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "":
+		fmt.Fprintf(&b, "# %s\n\n", sv.book.Info().Title)
+		b.WriteString("=> /daily Verse of the day\n=> /random A random verse\n=> /chapters Chapters\n")
+	case len(parts) == 1 && parts[0] == "daily":
+		slokas := sv.book.Data().Slokas
+		s := slokas[periodIndex(dayKey(time.Now().In(sv.loc)), len(slokas))]
+		sv.r.gemtext(&b, s)
+		sv.geminiLinks(&b, s)
+	case len(parts) == 1 && parts[0] == "random":
+		slokas := sv.book.Data().Slokas
+		sv.mu.Lock()
+		s := slokas[sv.rng.Intn(len(slokas))]
+		sv.mu.Unlock()
+		sv.r.gemtext(&b, s)
+		sv.geminiLinks(&b, s)
+	case len(parts) == 1 && parts[0] == "chapters":
+		fmt.Fprintf(&b, "# %s\n\n", sv.book.Info().Title)
+		for _, c := range sv.book.Data().Chapters {
+			fmt.Fprintf(&b, "=> /chapter/%d %s %d: %s\n", c.ChapterNumber, label("Chapter", sv.r.lang), c.ChapterNumber, chapterName(c, sv.r.lang))
+		}
+	case len(parts) == 2 && parts[0] == "chapter":
+		n, err := strconv.Atoi(parts[1])
+		c, ok := sv.book.Chapter(n)
+		if err != nil || !ok {
+			return geminiNotFound, fmt.Sprintf("chapter %s not found", parts[1])
+		}
+		sv.r.gemtextChapter(&b, c)
+		for _, s := range inOrder(sv.book.Verses(n)) {
+			text, _ := sv.r.resolve(s).translation(s)
+			fmt.Fprintf(&b, "=> /verse/%d/%d %d.%d %s\n", s.Chapter, s.Verse, s.Chapter, s.Verse, truncate(cleanTranslation(text), 60))
+		}
+		b.WriteString("\n=> /chapters Chapters\n")
+	case len(parts) == 3 && parts[0] == "verse":
+		chapter, err1 := strconv.Atoi(parts[1])
+		verse, err2 := strconv.Atoi(parts[2])
+		s, ok := sv.book.Get(chapter, verse)
+		if err1 != nil || err2 != nil || !ok {
+			return geminiNotFound, fmt.Sprintf("chapter %s, verse %s not found", parts[1], parts[2])
+		}
+		sv.r.gemtext(&b, s)
+		sv.geminiLinks(&b, s)
+	default:
+		return geminiNotFound, "not found"
+	}
+	return geminiSuccess, b.String()
+}
+
+// geminiLinks writes the links under a verse: the verses before and after
+// it in its chapter, and the chapter itself
+func (sv *server) geminiLinks(w io.Writer, s Sloka) {
+	if _, ok := sv.book.Get(s.Chapter, s.Verse-1); ok {
+		fmt.Fprintf(w, "=> /verse/%d/%d ← %d.%d\n", s.Chapter, s.Verse-1, s.Chapter, s.Verse-1)
+	}
+	if _, ok := sv.book.Get(s.Chapter, s.Verse+1); ok {
+		fmt.Fprintf(w, "=> /verse/%d/%d → %d.%d\n", s.Chapter, s.Verse+1, s.Chapter, s.Verse+1)
+	}
+	fmt.Fprintf(w, "=> /chapter/%d %s %d\n=> / %s\n", s.Chapter, label("Chapter", sv.r.lang), s.Chapter, sv.book.Info().Title)
+}
+
+// gemtextChapter writes the chapter heading and meaning as gemtext
+func (r renderer) gemtextChapter(w io.Writer, c Chapter) {
+	fmt.Fprintf(w, "# %s %d: %s\n\n", label("Chapter", r.lang), c.ChapterNumber, chapterName(c, r.lang))
+	if meaning := localized(c.Meaning.En, c.Meaning.Hi, r.lang); meaning != "" {
+		fmt.Fprintf(w, "%s: %s\n\n", label("Meaning", r.lang), meaning)
+	}
+}
+
+// gemtext writes s as gemtext: a heading, the Sanskrit lines quoted, the
+// transliteration and the translation, each a line that clients wrap
+func (r renderer) gemtext(w io.Writer, s Sloka) {
+	r = r.resolve(s)
+	fmt.Fprintf(w, "## %s %d, %s %d\n\n", label("Chapter", r.lang), s.Chapter, label("Verse", r.lang), s.Verse)
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(w, "> %s\n", line)
+		}
+	}
+	fmt.Fprintln(w)
+	for _, line := range transliterationLines(r.transliteration(s)) {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+	text, author := r.translation(s)
+	if strings.TrimSpace(text) == "" {
+		fmt.Fprintf(w, "%s\n\n", tr(r.lang, "(no translation available for this verse)"))
+		return
+	}
+	fmt.Fprintf(w, "%s\n— %s\n\n", cleanTranslation(text), author)
+	if r.commentary && r.source != Chinmay {
+		if text, ok := r.commentaryText(s); ok {
+			fmt.Fprintf(w, "%s\n— %s\n\n", cleanTranslation(text), r.commentaryAuthor(s))
+		}
+	}
+}
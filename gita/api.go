@@ -0,0 +1,85 @@
+package gita
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the base URL of the public vedicscriptures Bhagavad Gita
+// API, which serves verses in the gita.json schema
+const DefaultAPIURL = "https://vedicscriptures.github.io"
+
+// API fetches verses over HTTP from a server with the vedicscriptures API:
+// GET {BaseURL}/slok/{chapter}/{verse} returns one sloka as JSON.
+type API struct {
+	BaseURL string
+	Client  *http.Client
+	// Retries is how many times a failed request is repeated; requests for
+	// verses the server does not have are not retried
+	Retries int
+}
+
+// NewAPI returns an API for baseURL whose requests time out after timeout
+func NewAPI(baseURL string, timeout time.Duration) *API {
+	return &API{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: timeout},
+		Retries: 2,
+	}
+}
+
+// errNotRetryable marks failures that another attempt cannot fix
+var errNotRetryable = errors.New("not retryable")
+
+// Sloka fetches the sloka at the given chapter and verse, retrying failed
+// requests with a growing delay
+func (a *API) Sloka(chapter, verse int) (Sloka, error) {
+	url := fmt.Sprintf("%s/slok/%d/%d", a.BaseURL, chapter, verse)
+	var err error
+	for attempt := 0; attempt <= a.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		var s Sloka
+		if s, err = a.get(url); err == nil {
+			return s, nil
+		}
+		if errors.Is(err, errNotRetryable) {
+			break
+		}
+	}
+	return Sloka{}, err
+}
+
+// get performs one request for the sloka at url
+func (a *API) get(url string) (Sloka, error) {
+	resp, err := a.Client.Get(url)
+	if err != nil {
+		return Sloka{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return Sloka{}, fmt.Errorf("%s: %s", url, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return Sloka{}, fmt.Errorf("%s: %s (%w)", url, resp.Status, errNotRetryable)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return Sloka{}, err
+	}
+	var s Sloka
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Sloka{}, fmt.Errorf("%s: %v (%w)", url, err, errNotRetryable)
+	}
+	if s.Chapter == 0 || s.Verse == 0 {
+		return Sloka{}, fmt.Errorf("%s: response is not a sloka (%w)", url, errNotRetryable)
+	}
+	return s, nil
+}
@@ -0,0 +1,31 @@
+//go:build ignore
+
+// gen converts the JSON dataset of each text in texts.json, such as
+// gita.json, into the gob form the package embeds, such as gita.gob. Run it
+// with go generate after editing a dataset or adding a text.
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log"
+	"os"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+func main() {
+	for _, t := range gita.Texts() {
+		g, err := gita.LoadFile(t.Name + ".json")
+		if err != nil {
+			log.Fatalf("%s: %v", t.Name, err)
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(g.AllSlokas); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(t.Name+".gob", buf.Bytes(), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
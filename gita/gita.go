@@ -0,0 +1,200 @@
+// Package gita gives access to the verses of the Bhagavad Gita, and of any
+// other scripture listed in texts.json, embedded in gitasay: chapters, slokas
+// with their Sanskrit text and transliteration, and translations and
+// commentaries by several authors.
+//
+//	g, err := gita.Load()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	s, _ := g.Get(2, 47)
+//	fmt.Println(s.Siva.Et)
+package gita
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+)
+
+// the datasets are embedded as gob, made by gen.go from the JSON of each
+// text, which decodes several times faster and keeps startup quick in shell
+// prompts
+//
+//go:generate go run gen.go
+
+// Chapter represents information about a chapter
+type Chapter struct {
+	ChapterNumber   int    `json:"chapter_number"`
+	VersesCount     int    `json:"verses_count"`
+	Name            string `json:"name"`
+	Translation     string `json:"translation,omitempty"`
+	Transliteration string `json:"transliteration,omitempty"`
+	Meaning         struct {
+		En string `json:"en,omitempty"`
+		Hi string `json:"hi,omitempty"`
+	} `json:"meaning,omitempty"`
+	Summary struct {
+		En string `json:"en,omitempty"`
+		Hi string `json:"hi,omitempty"`
+	} `json:"summary,omitempty"`
+}
+
+// Sloka represents a verse
+type Sloka struct {
+	ID              string `json:"_id"`
+	Chapter         int    `json:"chapter"`
+	Verse           int    `json:"verse"`
+	Slok            string `json:"slok"`
+	Transliteration string `json:"transliteration"`
+	// Padapatha is the Sanskrit with the sandhi resolved into separate
+	// words, a line for each line of Slok; optional, see PadaLines
+	Padapatha string `json:"padapatha,omitempty"`
+	Tej       struct {
+		Author string `json:"author"`
+		Ht     string `json:"ht"`
+	} `json:"tej"`
+	Siva struct {
+		Author string `json:"author"`
+		Et     string `json:"et"`
+		Ec     string `json:"ec"`
+	} `json:"siva"`
+	Purohit struct {
+		Author string `json:"author"`
+		Et     string `json:"et"`
+	} `json:"purohit"`
+	Chinmay struct {
+		Author string `json:"author"`
+		Hc     string `json:"hc"`
+	} `json:"chinmay"`
+	San struct {
+		Author string `json:"author"`
+		Et     string `json:"et"`
+	} `json:"san"`
+	Adi struct {
+		Author string `json:"author"`
+		Et     string `json:"et"`
+	} `json:"adi"`
+}
+
+// AllSlokas stores all the verses, in the gita.json schema
+type AllSlokas struct {
+	Chapters []Chapter `json:"chapters"`
+	Slokas   []Sloka   `json:"slokas"`
+}
+
+// Gita is a loaded dataset with lookups by chapter, verse and id. Despite
+// the name it holds any text; Text says which.
+type Gita struct {
+	AllSlokas
+	Text      Text
+	verses    map[[2]int]int
+	chapters  map[int]int
+	byID      map[string]int
+	inChapter map[int][]Sloka
+}
+
+// Load returns the Bhagavad Gita embedded in the package
+func Load() (*Gita, error) {
+	return Open("gita")
+}
+
+// LoadFile reads and parses a dataset file in the gita.json schema
+func LoadFile(path string) (*Gita, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a dataset in the gita.json schema. Errors give the line and
+// column of malformed JSON, or the sloka or chapter that breaks the schema.
+func Parse(data []byte) (*Gita, error) {
+	var all AllSlokas
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, jsonError(data, err)
+	}
+	if len(all.Slokas) == 0 {
+		return nil, errors.New("no slokas found in the JSON data")
+	}
+	if err := validate(all); err != nil {
+		return nil, err
+	}
+	return New(all), nil
+}
+
+// New indexes data, which must not change afterwards, as the Bhagavad Gita;
+// set Text for another scripture
+func New(data AllSlokas) *Gita {
+	g := &Gita{
+		AllSlokas: data,
+		Text:      textIndex()[0],
+		verses:    make(map[[2]int]int, len(data.Slokas)),
+		chapters:  make(map[int]int, len(data.Chapters)),
+		byID:      make(map[string]int, len(data.Slokas)),
+		inChapter: make(map[int][]Sloka, len(data.Chapters)),
+	}
+	for i, c := range data.Chapters {
+		g.chapters[c.ChapterNumber] = i
+	}
+	for i, s := range data.Slokas {
+		g.verses[[2]int{s.Chapter, s.Verse}] = i
+		g.byID[s.ID] = i
+		g.inChapter[s.Chapter] = append(g.inChapter[s.Chapter], s)
+	}
+	return g
+}
+
+// Info describes the text g holds
+func (g *Gita) Info() Text {
+	return g.Text
+}
+
+// Data returns every chapter and sloka of g
+func (g *Gita) Data() AllSlokas {
+	return g.AllSlokas
+}
+
+// Get returns the sloka at the given chapter and verse
+func (g *Gita) Get(chapter, verse int) (Sloka, bool) {
+	i, ok := g.verses[[2]int{chapter, verse}]
+	if !ok {
+		return Sloka{}, false
+	}
+	return g.Slokas[i], true
+}
+
+// ByID returns the sloka with the given dataset id, e.g. BG2.47
+func (g *Gita) ByID(id string) (Sloka, bool) {
+	i, ok := g.byID[id]
+	if !ok {
+		return Sloka{}, false
+	}
+	return g.Slokas[i], true
+}
+
+// Chapter returns the chapter with the given number
+func (g *Gita) Chapter(n int) (Chapter, bool) {
+	i, ok := g.chapters[n]
+	if !ok {
+		return Chapter{}, false
+	}
+	return g.Chapters[i], true
+}
+
+// Verses returns the slokas of chapter n in dataset order. The slice is
+// shared and must not be modified.
+func (g *Gita) Verses(n int) []Sloka {
+	return g.inChapter[n]
+}
+
+// Random returns a random sloka drawn from rng, or from the default source
+// when rng is nil
+func (g *Gita) Random(rng *rand.Rand) Sloka {
+	if rng == nil {
+		return g.Slokas[rand.Intn(len(g.Slokas))]
+	}
+	return g.Slokas[rng.Intn(len(g.Slokas))]
+}
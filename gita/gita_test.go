@@ -0,0 +1,49 @@
+package gita
+
+import "testing"
+
+func TestByID(t *testing.T) {
+	g, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		id             string
+		chapter, verse int
+		ok             bool
+	}{
+		{"BG1.1", 1, 1, true},
+		{"BG2.47", 2, 47, true},
+		{"BG18.66", 18, 66, true},
+		{"BG18.78", 18, 78, true},
+		{"BG18.79", 0, 0, false},
+		{"BG0.1", 0, 0, false},
+		{"bg2.47", 0, 0, false},
+		{"2.47", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		s, ok := g.ByID(tt.id)
+		if ok != tt.ok || s.Chapter != tt.chapter || s.Verse != tt.verse {
+			t.Errorf("ByID(%q) = %d.%d, %v; want %d.%d, %v", tt.id, s.Chapter, s.Verse, ok, tt.chapter, tt.verse, tt.ok)
+		}
+		if ok && s.ID != tt.id {
+			t.Errorf("ByID(%q) returned the verse with id %q", tt.id, s.ID)
+		}
+	}
+}
+
+func TestByIDMatchesGet(t *testing.T) {
+	for _, text := range Texts() {
+		g, err := Open(text.Name)
+		if err != nil {
+			t.Fatalf("%s: %v", text.Name, err)
+		}
+		for _, s := range g.Data().Slokas {
+			got, ok := g.ByID(s.ID)
+			if want, _ := g.Get(s.Chapter, s.Verse); !ok || got.ID != want.ID {
+				t.Errorf("%s: ByID(%q) = %q, %v; want %q", text.Name, s.ID, got.ID, ok, want.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package gita
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed glossary.json
+var embeddedGlossary []byte
+
+// Term is an entry of the glossary of Sanskrit terms
+type Term struct {
+	Name       string   `json:"name"`       // the term without diacritics, e.g. "sthitaprajna"
+	IAST       string   `json:"iast"`       // e.g. "sthitaprajña"
+	Devanagari string   `json:"devanagari"` // e.g. "स्थितप्रज्ञ"
+	Meaning    string   `json:"meaning"`
+	See        []string `json:"see,omitempty"` // names of related terms
+	// Forms are the Devanagari stems whose presence in a verse's Sanskrit
+	// counts as an occurrence, including the forms sandhi joins onto the
+	// previous word
+	Forms []string `json:"forms"`
+}
+
+// glossary maps each term's name to its entry, parsed once from the
+// embedded glossary.json
+var glossary = sync.OnceValue(func() map[string]Term {
+	var terms map[string]Term
+	if err := json.Unmarshal(embeddedGlossary, &terms); err != nil {
+		panic("gita: invalid embedded glossary.json: " + err.Error())
+	}
+	for name, t := range terms {
+		t.Name = name
+		terms[name] = t
+	}
+	return terms
+})
+
+// Terms returns the names of the glossary's terms in sorted order
+func Terms() []string {
+	names := make([]string, 0, len(glossary()))
+	for name := range glossary() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Define returns the glossary entry of word, given without diacritics as in
+// "sthitaprajna", in IAST as in "sthitaprajña" or in Devanagari, ignoring
+// case
+func Define(word string) (Term, bool) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if t, ok := glossary()[word]; ok {
+		return t, true
+	}
+	for _, t := range glossary() {
+		if word == t.IAST || word == t.Devanagari {
+			return t, true
+		}
+	}
+	return Term{}, false
+}
+
+// Occurrences returns the slokas of s whose Sanskrit contains one of the
+// forms of t, in dataset order
+func Occurrences(s Scripture, t Term) []Sloka {
+	var slokas []Sloka
+	for _, sloka := range s.Data().Slokas {
+		for _, form := range t.Forms {
+			if strings.Contains(sloka.Slok, form) {
+				slokas = append(slokas, sloka)
+				break
+			}
+		}
+	}
+	return slokas
+}
@@ -0,0 +1,142 @@
+package gita
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// jsonError rewrites the errors of encoding/json with the line and column of
+// the offending byte of data, and the field for type mismatches
+func jsonError(data []byte, err error) error {
+	var syntax *json.SyntaxError
+	var mismatch *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		line, col := position(data, syntax.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, syntax)
+	case errors.As(err, &mismatch):
+		line, col := position(data, mismatch.Offset)
+		return fmt.Errorf("line %d, column %d: field %s: expected %s, got %s",
+			line, col, mismatch.Field, mismatch.Type, mismatch.Value)
+	}
+	return err
+}
+
+// position returns the 1-based line and column of offset in data
+func position(data []byte, offset int64) (line, col int) {
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// validate checks what the lookups rely on: every sloka has a positive
+// chapter and verse, no verse or chapter appears twice, and ids are unique
+func validate(all AllSlokas) error {
+	chapters := make(map[int]bool)
+	for i, c := range all.Chapters {
+		switch {
+		case c.ChapterNumber < 1:
+			return fmt.Errorf("chapters[%d]: chapter_number must be positive", i)
+		case chapters[c.ChapterNumber]:
+			return fmt.Errorf("chapters[%d]: chapter %d appears twice", i, c.ChapterNumber)
+		}
+		chapters[c.ChapterNumber] = true
+	}
+	verses := make(map[[2]int]bool)
+	ids := make(map[string]bool)
+	for i, s := range all.Slokas {
+		key := [2]int{s.Chapter, s.Verse}
+		switch {
+		case s.Chapter < 1 || s.Verse < 1:
+			return fmt.Errorf("slokas[%d]: chapter and verse must be positive", i)
+		case verses[key]:
+			return fmt.Errorf("slokas[%d]: chapter %d, verse %d appears twice", i, s.Chapter, s.Verse)
+		case s.ID != "" && ids[s.ID]:
+			return fmt.Errorf("slokas[%d]: _id %s appears twice", i, s.ID)
+		}
+		verses[key] = true
+		ids[s.ID] = true
+	}
+	return nil
+}
+
+// Merge returns g with the dataset data, in the gita.json schema, laid over
+// it: a chapter or sloka of data replaces only the fields it lists of the
+// one with the same number, and chapters and slokas g lacks are added. g is
+// not changed.
+func (g *Gita) Merge(data []byte) (*Gita, error) {
+	var overlay struct {
+		Chapters []json.RawMessage `json:"chapters"`
+		Slokas   []json.RawMessage `json:"slokas"`
+	}
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return nil, jsonError(data, err)
+	}
+
+	all := AllSlokas{
+		Chapters: append([]Chapter(nil), g.Chapters...),
+		Slokas:   append([]Sloka(nil), g.Slokas...),
+	}
+	for i, raw := range overlay.Chapters {
+		var key struct {
+			ChapterNumber int `json:"chapter_number"`
+		}
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return nil, fmt.Errorf("chapters[%d]: %v", i, err)
+		}
+		if j, ok := g.chapters[key.ChapterNumber]; ok {
+			if err := json.Unmarshal(raw, &all.Chapters[j]); err != nil {
+				return nil, fmt.Errorf("chapters[%d]: %v", i, err)
+			}
+			continue
+		}
+		var c Chapter
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("chapters[%d]: %v", i, err)
+		}
+		all.Chapters = append(all.Chapters, c)
+	}
+	for i, raw := range overlay.Slokas {
+		var key struct {
+			Chapter int `json:"chapter"`
+			Verse   int `json:"verse"`
+		}
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return nil, fmt.Errorf("slokas[%d]: %v", i, err)
+		}
+		if j, ok := g.verses[[2]int{key.Chapter, key.Verse}]; ok {
+			if err := json.Unmarshal(raw, &all.Slokas[j]); err != nil {
+				return nil, fmt.Errorf("slokas[%d]: %v", i, err)
+			}
+			continue
+		}
+		var s Sloka
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("slokas[%d]: %v", i, err)
+		}
+		if s.ID == "" {
+			s.ID = fmt.Sprintf("%s%d.%d", g.Text.Abbrev, s.Chapter, s.Verse)
+		}
+		all.Slokas = append(all.Slokas, s)
+	}
+	if err := validate(all); err != nil {
+		return nil, err
+	}
+
+	// added verses extend their chapter's count
+	counts := make(map[int]int)
+	for _, s := range all.Slokas {
+		counts[s.Chapter]++
+	}
+	for i := range all.Chapters {
+		c := &all.Chapters[i]
+		c.VersesCount = max(c.VersesCount, counts[c.ChapterNumber])
+	}
+	merged := New(all)
+	merged.Text = g.Text
+	return merged, nil
+}
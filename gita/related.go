@@ -0,0 +1,36 @@
+package gita
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sync"
+)
+
+//go:embed related.json
+var embeddedRelated []byte
+
+// relatedIndex maps the ids of slokas to the ids of the two to four slokas
+// most related to them in theme, parsed once from the embedded related.json
+var relatedIndex = sync.OnceValue(func() map[string][]string {
+	var index map[string][]string
+	if err := json.Unmarshal(embeddedRelated, &index); err != nil {
+		panic("gita: invalid embedded related.json: " + err.Error())
+	}
+	return index
+})
+
+// Related returns the slokas cross-referenced from s in the curated order,
+// none for verses without cross-references or for other texts. Ids missing
+// from g are skipped.
+func (g *Gita) Related(s Sloka) []Sloka {
+	if g.Text.Name != "gita" {
+		return nil
+	}
+	var slokas []Sloka
+	for _, id := range relatedIndex()[s.ID] {
+		if r, found := g.ByID(id); found {
+			slokas = append(slokas, r)
+		}
+	}
+	return slokas
+}
@@ -0,0 +1,93 @@
+package gita
+
+import (
+	"bytes"
+	"embed"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// files holds texts.json and the gob datasets go generate makes from each
+// text's NAME.json
+//
+//go:embed texts.json *.gob
+var files embed.FS
+
+// Text describes one of the embedded scriptures. Adding a text takes only
+// data: its NAME.json in the gita.json schema and an entry in texts.json.
+type Text struct {
+	Name   string `json:"name"`   // selects it with -text and names its dataset
+	Title  string `json:"title"`  // e.g. "Bhagavad Gita"
+	Abbrev string `json:"abbrev"` // starts short citations and ids, e.g. "BG" in "BG 2.47"
+}
+
+// Scripture is a text with chapters and verses to show, search and export.
+// *Gita implements it for every text, whose datasets share one schema.
+type Scripture interface {
+	// Info describes the text
+	Info() Text
+	// Data returns every chapter and verse in dataset order; the slices are
+	// shared and must not be modified
+	Data() AllSlokas
+	Get(chapter, verse int) (Sloka, bool)
+	ByID(id string) (Sloka, bool)
+	Chapter(n int) (Chapter, bool)
+	Verses(n int) []Sloka
+	Random(rng *rand.Rand) Sloka
+	Topic(topic string) ([]Sloka, bool)
+	Related(s Sloka) []Sloka
+}
+
+// textIndex is the parsed texts.json, the Bhagavad Gita first
+var textIndex = sync.OnceValue(func() []Text {
+	data, err := files.ReadFile("texts.json")
+	if err != nil {
+		panic("gita: missing embedded texts.json: " + err.Error())
+	}
+	var texts []Text
+	if err := json.Unmarshal(data, &texts); err != nil {
+		panic("gita: invalid embedded texts.json: " + err.Error())
+	}
+	return texts
+})
+
+// Texts returns the embedded scriptures, the Bhagavad Gita first
+func Texts() []Text {
+	return textIndex()
+}
+
+// Lookup returns the embedded text called name, e.g. "gita"
+func Lookup(name string) (Text, bool) {
+	for _, t := range textIndex() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Text{}, false
+}
+
+// Open returns the embedded text called name
+func Open(name string) (*Gita, error) {
+	t, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown text %q", name)
+	}
+	data, err := files.ReadFile(name + ".gob")
+	if err != nil {
+		return nil, fmt.Errorf("%s: dataset not generated; run go generate", name)
+	}
+	var all AllSlokas
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&all); err != nil {
+		return nil, err
+	}
+	if len(all.Slokas) == 0 {
+		return nil, errors.New("no slokas found in the embedded data")
+	}
+	g := New(all)
+	g.Text = t
+	return g, nil
+}
@@ -0,0 +1,48 @@
+package gita
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+//go:embed topics.json
+var embeddedTopics []byte
+
+// topicIndex maps each topic tag to the ids of its slokas, parsed once from
+// the embedded topics.json
+var topicIndex = sync.OnceValue(func() map[string][]string {
+	var index map[string][]string
+	if err := json.Unmarshal(embeddedTopics, &index); err != nil {
+		panic("gita: invalid embedded topics.json: " + err.Error())
+	}
+	return index
+})
+
+// Topics returns the topic tags, such as "karma-yoga" or "equanimity", in
+// sorted order
+func Topics() []string {
+	names := make([]string, 0, len(topicIndex()))
+	for name := range topicIndex() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Topic returns the slokas tagged with topic in chapter and verse order, and
+// whether the topic exists. Tagged ids missing from g are skipped.
+func (g *Gita) Topic(topic string) ([]Sloka, bool) {
+	ids, ok := topicIndex()[topic]
+	if !ok {
+		return nil, false
+	}
+	var slokas []Sloka
+	for _, id := range ids {
+		if s, found := g.ByID(id); found {
+			slokas = append(slokas, s)
+		}
+	}
+	return slokas, true
+}
@@ -0,0 +1,30 @@
+package gita
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sync"
+)
+
+//go:embed weights.json
+var embeddedWeights []byte
+
+// weights maps the ids of the best-known slokas to their curated weight,
+// parsed once from the embedded weights.json
+var weights = sync.OnceValue(func() map[string]int {
+	var w map[string]int
+	if err := json.Unmarshal(embeddedWeights, &w); err != nil {
+		panic("gita: invalid embedded weights.json: " + err.Error())
+	}
+	return w
+})
+
+// Weight returns how strongly the sloka with id is favored when picking by
+// popularity: its curated weight for the well-known verses, from 5 to 10,
+// and 1 for every other verse
+func Weight(id string) int {
+	if w, ok := weights()[id]; ok {
+		return w
+	}
+	return 1
+}
@@ -0,0 +1,180 @@
+package gita
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// WordMeaning is a word or phrase of a verse with its English gloss
+type WordMeaning struct {
+	Word    string `json:"word"`
+	Meaning string `json:"meaning"`
+}
+
+// glossNumber matches the "2.47" verse number that opens the glosses
+var glossNumber = regexp.MustCompile(`^\s*[\d.]+\s*`)
+
+// WordMeanings returns the word-by-word glosses (padas) of s in verse order,
+// or nil when it has none. They are taken from the start of Swami Sivananda's
+// commentary, where each Sanskrit word is followed by its meaning and the
+// entries are separated by "?". Parts without Sanskrit continue the previous
+// meaning, as the dataset also uses "?" for the commas inside a gloss.
+func (s Sloka) WordMeanings() []WordMeaning {
+	text := s.Siva.Ec
+	if i := strings.Index(text, "Commentary"); i >= 0 {
+		text = strings.TrimSuffix(strings.TrimSpace(text[:i]), "No")
+	}
+	text = glossNumber.ReplaceAllString(text, "")
+
+	var words []WordMeaning
+	for _, part := range strings.Split(text, "?") {
+		fields := strings.Fields(part)
+		n := 0
+		for n < len(fields) && isDevanagari(fields[n]) {
+			n++
+		}
+		meaning := strings.Join(fields[n:], " ")
+		switch {
+		case n > 0:
+			words = append(words, WordMeaning{Word: strings.Join(fields[:n], " "), Meaning: meaning})
+		case meaning != "" && len(words) > 0:
+			last := &words[len(words)-1]
+			last.Meaning = strings.TrimPrefix(last.Meaning+", "+meaning, ", ")
+		}
+	}
+	if len(words) > 0 {
+		last := &words[len(words)-1]
+		last.Meaning = strings.TrimRight(last.Meaning, ". ")
+	}
+	return words
+}
+
+// isDevanagari reports whether word contains a Devanagari letter
+func isDevanagari(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool {
+		return unicode.Is(unicode.Devanagari, r) && unicode.IsLetter(r)
+	}) >= 0
+}
+
+// PadaLines returns the pada-patha of s, the words of each non-blank line
+// of its Sanskrit with the sandhi between them undone, a string for each
+// such line. The dataset's padapatha field is used when it has as many
+// lines; otherwise the words of WordMeanings are laid over the lines, left
+// blank for lines naming the speaker. It is nil when s has neither.
+func (s Sloka) PadaLines() []string {
+	lines := nonBlankLines(s.Slok)
+	if padas := nonBlankLines(s.Padapatha); len(padas) == len(lines) {
+		return padas
+	}
+	var words []string
+	for _, w := range s.WordMeanings() {
+		words = append(words, strings.Fields(w.Word)...)
+	}
+	if len(words) == 0 || len(lines) == 0 {
+		return nil
+	}
+
+	// the space-separated chunks of the verse, each one or more words
+	// joined by sandhi, with the line they are on
+	type chunk struct {
+		text string
+		line int
+	}
+	var chunks []chunk
+	for i, line := range lines {
+		if strings.HasSuffix(strings.TrimRight(line, " |।॥"), "वाच") {
+			continue
+		}
+		for _, c := range strings.Fields(line) {
+			if letters(c) > 0 {
+				chunks = append(chunks, chunk{c, i})
+			}
+		}
+	}
+	padas := make([][]string, len(lines))
+	for i, c := range chunks {
+		n := len(words)
+		if i < len(chunks)-1 {
+			n = joined(words, c.text, chunks[i+1].text)
+		}
+		padas[c.line] = append(padas[c.line], words[:n]...)
+		words = words[n:]
+		if len(words) == 0 {
+			break
+		}
+	}
+	result := make([]string, len(lines))
+	for i, p := range padas {
+		result[i] = strings.Join(p, " ")
+	}
+	return result
+}
+
+// joined returns how many of words the chunk of verse joins, the count
+// whose letters come closest to the chunk's, preferring counts after which
+// the next word starts like the next chunk, as sandhi rarely changes the
+// first letter of a chunk
+func joined(words []string, chunk, next string) int {
+	target := letters(chunk)
+	best, bestDiff, bestStarts := 1, -1, false
+	n := 0
+	for k := 1; k < len(words); k++ {
+		n += letters(words[k-1])
+		if n > target+target/2+2 {
+			break
+		}
+		diff, starts := abs(n-target), firstLetter(words[k]) == firstLetter(next)
+		if bestDiff < 0 || (starts && !bestStarts) || (starts == bestStarts && diff < bestDiff) {
+			best, bestDiff, bestStarts = k, diff, starts
+		}
+	}
+	return best
+}
+
+// firstLetter returns the first Devanagari letter of s, or 0
+func firstLetter(s string) rune {
+	for _, r := range s {
+		if isLetter(r) {
+			return r
+		}
+	}
+	return 0
+}
+
+// nonBlankLines returns the trimmed lines of text that are not blank
+func nonBlankLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// letters counts the Devanagari letters of s, leaving out the vowel signs,
+// virama and other marks
+func letters(s string) int {
+	n := 0
+	for _, r := range s {
+		if isLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// isLetter reports whether r is a Devanagari letter, counting the anusvara,
+// which stands for the m of a word ending in म् before another
+func isLetter(r rune) bool {
+	return r == 'ं' || unicode.Is(unicode.Devanagari, r) && unicode.IsLetter(r)
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package gita
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPadaLines(t *testing.T) {
+	g, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the embedded data has no padapatha, so these are split from the glosses
+	tests := []struct {
+		chapter, verse int
+		want           []string
+	}{
+		{1, 1, []string{
+			"", // "धृतराष्ट्र उवाच" names the speaker
+			"धर्मक्षेत्रे कुरुक्षेत्रे समवेताः युयुत्सवः",
+			"मामकाः पाण्डवाः च एव किम् अकुर्वत सञ्जय",
+		}},
+		{2, 47, []string{
+			"कर्मणि एव अधिकारः ते मा फलेषु कदाचन",
+			"मा कर्मफलहेतुः भूः मा ते सङ्गः अस्तु अकर्मणि",
+		}},
+	}
+	for _, tt := range tests {
+		s, ok := g.Get(tt.chapter, tt.verse)
+		if !ok {
+			t.Fatalf("no verse %d.%d", tt.chapter, tt.verse)
+		}
+		if s.Padapatha != "" {
+			t.Errorf("%d.%d has a padapatha; the test pins the split from the glosses", tt.chapter, tt.verse)
+		}
+		if got := s.PadaLines(); !slices.Equal(got, tt.want) {
+			t.Errorf("%d.%d: PadaLines = %q, want %q", tt.chapter, tt.verse, got, tt.want)
+		}
+	}
+}
+
+func TestPadaLinesField(t *testing.T) {
+	s := Sloka{Slok: "कर्मण्येवाधिकारस्ते |\nमा ते सङ्गोऽस्त्वकर्मणि ||", Padapatha: "कर्मणि एव अधिकारः ते\nमा ते सङ्गः अस्तु अकर्मणि"}
+	want := []string{"कर्मणि एव अधिकारः ते", "मा ते सङ्गः अस्तु अकर्मणि"}
+	if got := s.PadaLines(); !slices.Equal(got, want) {
+		t.Errorf("PadaLines = %q, want the padapatha field's %q", got, want)
+	}
+
+	// a padapatha with a different number of lines is not used
+	s.Padapatha = "कर्मणि एव अधिकारः ते"
+	if got := s.PadaLines(); got != nil {
+		t.Errorf("PadaLines = %q without a usable padapatha or glosses, want nil", got)
+	}
+}
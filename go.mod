@@ -1,3 +1,7 @@
 module github.com/ashish0kumar/gitasay
 
 go 1.23.2
+
+require golang.org/x/term v0.34.0
+
+require golang.org/x/sys v0.35.0
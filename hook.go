@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// git hooks gitasay installs, the default first
+const (
+	HookPrepareCommitMsg = "prepare-commit-msg"
+	HookPostCommit       = "post-commit"
+)
+
+// hookTypes lists the hooks accepted by -hook-install and -hook-uninstall
+var hookTypes = []string{HookPrepareCommitMsg, HookPostCommit}
+
+// hookMarker identifies the hooks gitasay wrote, so it never overwrites or
+// removes anyone else's
+const hookMarker = "# installed by gitasay"
+
+// hookFlags are the flags that, given to hook install, are passed on to
+// every verse the hook picks
+var hookFlags = []string{"translation", "auto-source", "c", "chapters", "topic", "fav-random", "select", "daily", "weekly", "text", "data"}
+
+// hookScript returns the hook of type kind, which runs exe with args to pick
+// the verse. Repositories where git config gitasay.enabled is false are
+// skipped.
+//
+// prepare-commit-msg adds the verse as a comment below the message being
+// edited, so it shows in the editor but is not committed; it leaves merges,
+// squashes, amends and -m messages alone. post-commit prints a verse after
+// each commit.
+func hookScript(kind, exe string, args []string) string {
+	command := shellQuote(exe) + " -commit-msg -no-track"
+	for _, arg := range args {
+		command += " " + shellQuote(arg)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/sh\n%s; remove with: gitasay hook uninstall %s\n", hookMarker, kind)
+	b.WriteString("[ \"$(git config --bool gitasay.enabled)\" = false ] && exit 0\n")
+	switch kind {
+	case HookPostCommit:
+		fmt.Fprintf(&b, "%s || true\n", command)
+	default:
+		b.WriteString("case \"$2\" in message|merge|squash|commit) exit 0 ;; esac\n")
+		fmt.Fprintf(&b, "verse=$(%s) || exit 0\n", command)
+		b.WriteString("c=$(git config core.commentChar)\n")
+		b.WriteString("case \"$c\" in ''|auto) c='#' ;; esac\n")
+		b.WriteString("printf '%s\\n%s %s\\n' \"$c\" \"$c\" \"$verse\" >> \"$1\"\n")
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell when it holds anything but plain
+// word characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=,:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookArgs returns the hookFlags given on the command line as arguments for
+// the hook's gitasay
+func hookArgs() []string {
+	var args []string
+	for _, name := range hookFlags {
+		if flagSet(name) {
+			args = append(args, "-"+name+"="+flag.Lookup(name).Value.String())
+		}
+	}
+	return args
+}
+
+// hookPath returns where git looks for the hook of type kind in the
+// repository of the working directory, honoring core.hooksPath
+func hookPath(kind string) (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--git-path", filepath.Join("hooks", kind)).Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return "", fmt.Errorf("not in a git repository: %s", strings.TrimSpace(string(exit.Stderr)))
+	} else if err != nil {
+		return "", err
+	}
+	return filepath.Abs(strings.TrimSpace(string(out)))
+}
+
+// installHook writes the hook of type kind, passing args to the verse. An
+// existing hook that gitasay did not write is kept unless force is set.
+func installHook(kind string, args []string, force bool) (string, error) {
+	path, err := hookPath(kind)
+	if err != nil {
+		return "", err
+	}
+	if existing, err := os.ReadFile(path); err == nil && !bytes.Contains(existing, []byte(hookMarker)) && !force {
+		return "", fmt.Errorf("%s already exists; move it away or pass -force to replace it", path)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "gitasay"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(hookScript(kind, exe, args)), 0o755); err != nil {
+		return "", err
+	}
+	// a replaced hook keeps its mode, which may not be executable
+	return path, os.Chmod(path, 0o755)
+}
+
+// uninstallHook removes the hook of type kind if gitasay wrote it
+func uninstallHook(kind string) (string, error) {
+	path, err := hookPath(kind)
+	if err != nil {
+		return "", err
+	}
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no %s hook is installed", kind)
+	} else if err != nil {
+		return "", err
+	}
+	if !bytes.Contains(existing, []byte(hookMarker)) {
+		return "", fmt.Errorf("%s was not installed by gitasay; leaving it", path)
+	}
+	return path, os.Remove(path)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// htmlStyle is the stylesheet of HTML exports, kept small so the document
+// reads well as is and is easy to restyle
+const htmlStyle = `body { max-width: 42em; margin: 2em auto; padding: 0 1em; font-family: Georgia, serif; line-height: 1.5; }
+.sanskrit { font-size: 1.15em; }
+.transliteration { font-style: italic; color: #555; }
+blockquote { margin: 1em 0; padding-left: 1em; border-left: 3px solid #ccc; }
+blockquote footer { color: #666; font-size: 0.9em; }
+`
+
+// htmlHeader starts an HTML document titled title in lang
+func htmlHeader(w io.Writer, title, lang string) {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n", lang)
+	fmt.Fprintf(w, "<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(w, "<style>\n%s</style>\n</head>\n<body>\n", htmlStyle)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(title))
+}
+
+// htmlFooter ends an HTML document started by htmlHeader
+func htmlFooter(w io.Writer) {
+	fmt.Fprintln(w, "</body>\n</html>")
+}
+
+// htmlChapter writes the chapter heading with its meaning; the heading's id
+// lets other pages link to it, e.g. #chapter-2
+func (r renderer) htmlChapter(w io.Writer, chapter Chapter) {
+	name := firstNonEmpty(chapter.Name, chapterName(chapter, r.lang))
+	fmt.Fprintf(w, "<h2 id=\"chapter-%d\">%s %d: %s</h2>\n", chapter.ChapterNumber,
+		label("Chapter", r.lang), chapter.ChapterNumber, html.EscapeString(name))
+	if alt := chapterName(chapter, r.lang); alt != name {
+		fmt.Fprintf(w, "<p><em>%s</em></p>\n", html.EscapeString(alt))
+	}
+	if meaning := localized(chapter.Meaning.En, chapter.Meaning.Hi, r.lang); meaning != "" {
+		fmt.Fprintf(w, "<p>%s: %s</p>\n", label("Meaning", r.lang), html.EscapeString(meaning))
+	}
+}
+
+// html writes s as an article, linkable by its id such as #BG2.47: the
+// Sanskrit and the transliteration one line per verse line, then the
+// translation, or each compared translation, as a quote with its author
+func (r renderer) html(w io.Writer, s Sloka) {
+	r = r.resolve(s)
+	fmt.Fprintf(w, "<article id=\"%s\">\n<h3>%s %d, %s %d</h3>\n", s.ID,
+		label("Chapter", r.lang), s.Chapter, label("Verse", r.lang), s.Verse)
+
+	var sanskrit []string
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sanskrit = append(sanskrit, html.EscapeString(line))
+		}
+	}
+	fmt.Fprintf(w, "<p class=\"sanskrit\" lang=\"sa\">%s</p>\n", strings.Join(sanskrit, "<br>\n"))
+
+	var roman []string
+	for _, line := range transliterationLines(r.transliteration(s)) {
+		roman = append(roman, html.EscapeString(line))
+	}
+	fmt.Fprintf(w, "<p class=\"transliteration\">%s</p>\n", strings.Join(roman, "<br>\n"))
+
+	var blocks []translationBlock
+	if r.allTranslations {
+		blocks = r.comparedTranslations(s)
+	} else {
+		text, author := r.translation(s)
+		blocks = append(blocks, translationBlock{text, author, r.source})
+	}
+	for _, b := range blocks {
+		fmt.Fprintf(w, "<blockquote lang=\"%s\">\n<p>%s</p>\n<footer>— %s</footer>\n</blockquote>\n",
+			sourceLang(b.source), html.EscapeString(strings.Join(strings.Fields(b.text), " ")),
+			html.EscapeString(b.author))
+	}
+	fmt.Fprintln(w, "</article>")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// the message catalogs, locales/LANG.json, map the English labels and
+// messages, format verbs included, to their translation; English needs none
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs holds the parsed catalogs by language
+var catalogs = sync.OnceValue(func() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic("gitasay: missing embedded locales: " + err.Error())
+	}
+	all := make(map[string]map[string]string)
+	for _, e := range entries {
+		data, err := localeFiles.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic("gitasay: " + err.Error())
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic("gitasay: invalid embedded " + e.Name() + ": " + err.Error())
+		}
+		all[strings.TrimSuffix(e.Name(), ".json")] = messages
+	}
+	return all
+})
+
+// uiLang is the language of the messages, set from -lang
+var uiLang = "en"
+
+// tr returns msg in lang, or msg itself when the catalog lacks it
+func tr(lang, msg string) string {
+	if t := catalogs()[lang][msg]; t != "" {
+		return t
+	}
+	return msg
+}
+
+// msgf formats the message format in the -lang language
+func msgf(format string, args ...any) string {
+	return fmt.Sprintf(tr(uiLang, format), args...)
+}
+
+// label returns the heading word for lang
+func label(word, lang string) string {
+	return tr(lang, word)
+}
+
+// envLang returns the language of the locale in LC_ALL, LC_MESSAGES or
+// LANG, such as hi for hi_IN.UTF-8, if it is one -lang accepts
+func envLang() (string, bool) {
+	locale := firstNonEmpty(os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG"))
+	name, _, _ := strings.Cut(locale, "_")
+	name, _, _ = strings.Cut(name, ".")
+	return parseLang(name)
+}
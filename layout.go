@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	boxMargin   = 4  // width taken by the box border and padding
+	centerWidth = 72 // line width of -center when -width is not given
+)
+
+// boxStyle is the set of characters a -box frame is drawn with
+type boxStyle struct {
+	topLeft, topRight, bottomLeft, bottomRight, horizontal, vertical string
+}
+
+// boxStyles holds the frames -box-style can draw
+var boxStyles = map[string]boxStyle{
+	"rounded": {"╭", "╮", "╰", "╯", "─", "│"},
+	"single":  {"┌", "┐", "└", "┘", "─", "│"},
+	"double":  {"╔", "╗", "╚", "╝", "═", "║"},
+	"heavy":   {"┏", "┓", "┗", "┛", "━", "┃"},
+	"ascii":   {"+", "+", "+", "+", "-", "|"},
+}
+
+// boxStyleNames returns the available box styles in sorted order
+func boxStyleNames() []string {
+	names := make([]string, 0, len(boxStyles))
+	for name := range boxStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// blockLines returns the lines of text without the blank lines around it,
+// and the width of the widest, measured without ANSI escapes
+func blockLines(text string) ([]string, int) {
+	lines := strings.Split(strings.Trim(text, "\n"), "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, visibleWidth(line))
+	}
+	return lines, width
+}
+
+// boxed draws a frame in style around text, one space from its widest line,
+// so styled and Devanagari lines line up too
+func boxed(text string, style boxStyle) string {
+	lines, width := blockLines(text)
+	var b strings.Builder
+	b.WriteString("\n" + style.topLeft + strings.Repeat(style.horizontal, width+2) + style.topRight + "\n")
+	for _, line := range lines {
+		pad := strings.Repeat(" ", width-visibleWidth(line))
+		b.WriteString(style.vertical + " " + line + pad + " " + style.vertical + "\n")
+	}
+	b.WriteString(style.bottomLeft + strings.Repeat(style.horizontal, width+2) + style.bottomRight + "\n\n")
+	return b.String()
+}
+
+// centered indents text so its widest line is centered in screen columns,
+// keeping the lines aligned with each other
+func centered(text string, screen int) string {
+	lines, width := blockLines(text)
+	indent := strings.Repeat(" ", max(0, (screen-width)/2))
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return "\n" + strings.Join(lines, "\n") + "\n\n"
+}
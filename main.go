@@ -1,76 +1,46 @@
 package main
 
 import (
-	"embed"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
 	"math/rand"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
+
+	"github.com/ashish0kumar/gitasay/gita"
+	"golang.org/x/term"
 )
 
-// Chapter represents information about a chapter
-type Chapter struct {
-	ChapterNumber   int    `json:"chapter_number"`
-	VersesCount     int    `json:"verses_count"`
-	Name            string `json:"name"`
-	Translation     string `json:"translation,omitempty"`
-	Transliteration string `json:"transliteration,omitempty"`
-	Meaning         struct {
-		En string `json:"en,omitempty"`
-		Hi string `json:"hi,omitempty"`
-	} `json:"meaning,omitempty"`
-	Summary struct {
-		En string `json:"en,omitempty"`
-		Hi string `json:"hi,omitempty"`
-	} `json:"summary,omitempty"`
-}
-
-// Sloka represents a verse
-type Sloka struct {
-	ID              string `json:"_id"`
-	Chapter         int    `json:"chapter"`
-	Verse           int    `json:"verse"`
-	Slok            string `json:"slok"`
-	Transliteration string `json:"transliteration"`
-	Tej             struct {
-		Author string `json:"author"`
-		Ht     string `json:"ht"`
-	} `json:"tej"`
-	Siva struct {
-		Author string `json:"author"`
-		Et     string `json:"et"`
-		Ec     string `json:"ec"`
-	} `json:"siva"`
-	Purohit struct {
-		Author string `json:"author"`
-		Et     string `json:"et"`
-	} `json:"purohit"`
-	Chinmay struct {
-		Author string `json:"author"`
-		Hc     string `json:"hc"`
-	} `json:"chinmay"`
-	San struct {
-		Author string `json:"author"`
-		Et     string `json:"et"`
-	} `json:"san"`
-	Adi struct {
-		Author string `json:"author"`
-		Et     string `json:"et"`
-	} `json:"adi"`
-}
-
-// AllSlokas stores all the verses
-type AllSlokas struct {
-	Chapters []Chapter `json:"chapters"`
-	Slokas   []Sloka   `json:"slokas"`
-}
-
-//go:embed gita.json
-var gitaFS embed.FS // embed gita.json
+// dataset types, shared with the gita package
+type (
+	Chapter   = gita.Chapter
+	Sloka     = gita.Sloka
+	AllSlokas = gita.AllSlokas
+)
+
+// Build metadata, stamped by release builds with
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  = "dev"
+	date    = "dev"
+)
 
 // Translator constants
 const (
@@ -82,17 +52,151 @@ const (
 	Chinmay = "chinmay"
 )
 
-const displayWidth = 70 // max line width for wrapping
+// TranslatorInfo describes a translation source
+type TranslatorInfo struct {
+	Key    string `json:"key"`
+	Author string `json:"author"`
+	Lang   string `json:"lang"`
+}
+
+// translators lists every translation source in display order
+var translators = []TranslatorInfo{
+	{Key: Siva, Author: "Swami Sivananda", Lang: "en"},
+	{Key: Purohit, Author: "Shri Purohit Swami", Lang: "en"},
+	{Key: Adi, Author: "Swami Adidevananda", Lang: "en"},
+	{Key: San, Author: "Dr.S.Sankaranarayan", Lang: "en"},
+	{Key: Tej, Author: "Swami Tejomayananda", Lang: "hi"},
+	{Key: Chinmay, Author: "Swami Chinmayananda", Lang: "hi"},
+}
+
+// sourceLang returns the language of the translation source key
+func sourceLang(key string) string {
+	for _, t := range translators {
+		if t.Key == key {
+			return t.Lang
+		}
+	}
+	return ""
+}
+
+// citation styles accepted by -cite
+const (
+	CiteShort = "short"
+	CiteLong  = "long"
+	CiteNone  = "none"
+)
+
+// citeStyles lists the -cite styles
+var citeStyles = []string{CiteShort, CiteLong, CiteNone}
+
+// exit codes, so scripts can tell failures apart
+const (
+	exitError    = 1 // output or state could not be read or written, or a server, quiz or notifier failed
+	exitUsage    = 2 // invalid flags or flag values, as with flag parse errors
+	exitNotFound = 3 // no verse matched the chapter, verse, id or search
+	exitData     = 4 // the verse data could not be loaded
+)
+
+const (
+	displayWidth = 70 // line width when stdout is not a terminal
+	minWidth     = 20 // narrower widths are raised to this
+)
+
+// terminalWidth returns the width of the terminal on stdout. When stdout is
+// not a terminal the COLUMNS environment variable is used if set, else
+// displayWidth.
+func terminalWidth() int {
+	if width, _ := terminalSize(); width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return displayWidth
+}
+
+// terminalSize returns the columns and rows of the terminal on stdout, or
+// zeros when stdout is not one
+func terminalSize() (width, height int) {
+	if !isTerminal(os.Stdout) {
+		return 0, 0
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// screenLines returns how many terminal rows text takes at width, counting
+// the lines the terminal wraps
+func screenLines(text string, width int) int {
+	n := 0
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		n += max(1, (visibleWidth(line)+width-1)/max(width, 1))
+	}
+	return n
+}
+
+// unwrapped joins text into a single line, ignoring width, for consumers
+// that do their own wrapping
+func unwrapped(text string, width int) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// runeWidth returns the number of terminal columns r occupies: none for
+// combining marks such as the virama and most Devanagari vowel signs and for
+// format characters like the zero-width joiner, two for East Asian wide and
+// fullwidth characters, one otherwise
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo initials
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F,              // CJK ... Yi
+		r >= 0xAC00 && r <= 0xD7A3,                             // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF,                             // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F,                             // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60, r >= 0xFFE0 && r <= 0xFFE6, // fullwidth forms
+		r >= 0x1F300 && r <= 0x1F64F, r >= 0x1F900 && r <= 0x1F9FF, // emoji
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions
+		return 2
+	}
+	return 1
+}
+
+// textWidth returns the number of terminal columns s occupies
+func textWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// cutWidth returns the longest prefix of s that fits in width columns,
+// keeping combining marks with the letter they follow
+func cutWidth(s string, width int) string {
+	used := 0
+	for i, r := range s {
+		w := runeWidth(r)
+		if w > 0 && used+w > width {
+			return s[:i]
+		}
+		used += w
+	}
+	return s
+}
 
-// wrapText wraps text to fit the terminal width
-func wrapText(text string) string {
+// wrapText wraps text to lines of at most width columns
+func wrapText(text string, width int) string {
 	var result strings.Builder
 	current := 0
 
 	words := strings.Fields(text)
 	for i, word := range words {
-		wordLen := utf8.RuneCountInString(word)
-		if current+wordLen+1 > displayWidth && current > 0 {
+		wordLen := textWidth(word)
+		if current+wordLen+1 > width && current > 0 {
 			result.WriteString("\n")
 			current = 0
 		}
@@ -103,9 +207,7 @@ func wrapText(text string) string {
 		result.WriteString(word)
 		current += wordLen
 
-		if i < len(words)-1 && strings.ContainsAny(word, ".!?") &&
-			!strings.HasPrefix(words[i+1], ")") &&
-			!strings.HasPrefix(words[i+1], ",") {
+		if sentenceBreak(words, i) {
 			result.WriteString("\n")
 			current = 0
 		}
@@ -114,153 +216,3086 @@ func wrapText(text string) string {
 	return result.String()
 }
 
-// ANSI styling
-const (
-	Bold  = "\033[1m"
-	Dim   = "\033[2m"
-	Reset = "\033[0m"
+// justify pads the lines of wrapped text with spaces between the words so
+// that each fills width, except the last line of the text and of each
+// sentence, which keep their ragged end, and lines with so few words that
+// the gaps would more than quadruple
+func justify(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines)-1; i++ {
+		words, next := strings.Fields(lines[i]), strings.Fields(lines[i+1])
+		if len(words) < 2 || len(next) == 0 || sentenceBreak([]string{words[len(words)-1], next[0]}, 0) {
+			continue
+		}
+		gaps := len(words) - 1
+		spaces := width - textWidth(strings.Join(words, ""))
+		if spaces <= gaps || spaces > 4*gaps {
+			continue
+		}
+		var b strings.Builder
+		for j, word := range words {
+			b.WriteString(word)
+			if j < gaps {
+				// the leftmost gaps take the remainder
+				n := spaces / gaps
+				if j < spaces%gaps {
+					n++
+				}
+				b.WriteString(strings.Repeat(" ", n))
+			}
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hyphenate splits words longer than width into hyphenated pieces that fit,
+// preferring existing hyphens as break points
+func hyphenate(text string, width int) string {
+	if width < 2 {
+		return text
+	}
+	words := strings.Fields(text)
+	var pieces []string
+	for _, word := range words {
+		for textWidth(word) > width {
+			cut := cutWidth(word, width-1)
+			if i := strings.LastIndex(cutWidth(word, width), "-"); i > 0 {
+				cut = word[:i]
+			}
+			if cut == "" {
+				// a character wider than the line: let it overflow
+				break
+			}
+			pieces = append(pieces, cut+"-")
+			word = strings.TrimPrefix(word[len(cut):], "-")
+		}
+		pieces = append(pieces, word)
+	}
+	return strings.Join(pieces, " ")
+}
+
+// abbreviations end in a period without ending the sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "dr": true, "st": true, "sri": true, "shri": true,
+	"sj": true, "cf": true, "viz": true, "vs": true, "no": true, "ch": true,
+	"etc": true, "eg": true, "ie": true, "prof": true, "jr": true, "sr": true,
+	"vol": true, "pp": true, "ca": true, "lit": true, "skt": true, "vv": true,
+}
+
+// initialism matches dotted abbreviations such as "B.C." and "i.e."
+var initialism = regexp.MustCompile(`^(\pL\.)+$`)
+
+// verseNumber matches verse numbers such as "1.3." that start a translation
+var verseNumber = regexp.MustCompile(`^[\d.]+$`)
+
+// sentenceBreak reports whether a line break is forced after words[i]. Only
+// words ending in sentence punctuation count, so periods inside a word such
+// as "2.47" or the avagraha in "saṅgo.astvakarmaṇi" do not break the line.
+// The break also needs the next word to start a sentence: it must begin with
+// a capital or an uncased letter, and words[i] must not be an abbreviation,
+// an ellipsis or a verse number.
+func sentenceBreak(words []string, i int) bool {
+	if i >= len(words)-1 {
+		return false
+	}
+	word := strings.TrimRight(words[i], "\"')”’")
+	if !strings.ContainsAny(lastRune(word), ".!?") {
+		return false
+	}
+	if strings.HasSuffix(word, "..") || verseNumber.MatchString(word) || initialism.MatchString(word) {
+		return false
+	}
+	if abbreviations[strings.ToLower(strings.TrimLeft(strings.TrimSuffix(word, "."), "\"'(“‘"))] {
+		return false
+	}
+
+	next, _ := utf8.DecodeRuneInString(strings.TrimLeft(words[i+1], "\"'(“‘"))
+	return unicode.IsUpper(next) || (unicode.IsLetter(next) && !unicode.IsLower(next))
+}
+
+// lastRune returns the last rune of s as a string, or "" for an empty s
+func lastRune(s string) string {
+	r, size := utf8.DecodeLastRuneInString(s)
+	if size == 0 {
+		return ""
+	}
+	return string(r)
+}
+
+// wrapBalanced wraps text like wrapText but spreads the words of each
+// sentence evenly across lines instead of filling lines greedily
+func wrapBalanced(text string, width int) string {
+	var lines []string
+	words := strings.Fields(text)
+	start := 0
+	for i := range words {
+		if i == len(words)-1 || sentenceBreak(words, i) {
+			lines = append(lines, balanceLines(words[start:i+1], width)...)
+			start = i + 1
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// balanceLines splits words into lines no wider than width, minimizing the
+// sum of squared trailing space so the last line is not left short
+func balanceLines(words []string, width int) []string {
+	n := len(words)
+	lens := make([]int, n)
+	for i, w := range words {
+		lens[i] = textWidth(w)
+	}
+
+	// cost[i] is the minimal cost of laying out words[i:], next[i] the end of its first line
+	cost := make([]int, n+1)
+	next := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		cost[i] = -1
+		lineLen := -1
+		for j := i + 1; j <= n; j++ {
+			lineLen += lens[j-1] + 1
+			if lineLen > width && j > i+1 {
+				break
+			}
+			slack := width - lineLen
+			if slack < 0 {
+				slack = 0
+			}
+			c := slack*slack + cost[j]
+			if cost[i] < 0 || c < cost[i] {
+				cost[i] = c
+				next[i] = j
+			}
+		}
+	}
+
+	var lines []string
+	for i := 0; i < n; i = next[i] {
+		lines = append(lines, strings.Join(words[i:next[i]], " "))
+	}
+	return lines
+}
+
+// periodIndex deterministically maps a period key (e.g. "2024-W07") to an index in [0, n)
+func periodIndex(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// weekKey returns the ISO year-week of t, e.g. "2024-W07"
+func weekKey(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// escapeReplacer expands the escape sequences accepted in -prefix and -suffix
+var escapeReplacer = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t")
+
+// unescape expands \n, \t and \\ in user-supplied strings
+func unescape(s string) string {
+	return escapeReplacer.Replace(s)
+}
+
+// verseNumberPrefix matches the leading "2.47" or "।।2.47।।" marker of translations
+var verseNumberPrefix = regexp.MustCompile(`^\s*(।।)?\d+\.\d+\.?(।।)?\s*`)
+
+// cleanTranslation strips the verse number marker and collapses whitespace
+func cleanTranslation(text string) string {
+	text = verseNumberPrefix.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// truncate shortens text to at most max columns at a word boundary, ending with an ellipsis when cut
+func truncate(text string, max int) string {
+	if textWidth(text) <= max {
+		return text
+	}
+	if max < 1 {
+		return ""
+	}
+	cut := cutWidth(text, max-1)
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	cut = strings.TrimRight(cut, " ,;:")
+	return cut + "…"
+}
+
+const commitLineWidth = 72 // conventional max width of a commit message line
+
+// commitLine formats a sloka as a single plain line ending with its citation
+// in the text's abbreviation
+func commitLine(s Sloka, abbrev, translation string) string {
+	cite := fmt.Sprintf(" (%s %d.%d)", abbrev, s.Chapter, s.Verse)
+	text := truncate(cleanTranslation(translation), commitLineWidth-utf8.RuneCountInString(cite))
+	return text + cite
+}
+
+var (
+	htmlTag    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	htmlEntity = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z]+);`)
+)
+
+// hasHTML reports whether text contains HTML tags or entities
+func hasHTML(text string) bool {
+	return htmlTag.MatchString(text) || htmlEntity.MatchString(text)
+}
+
+// stripHTML removes HTML tags and unescapes entities
+func stripHTML(text string) string {
+	return html.UnescapeString(htmlTag.ReplaceAllString(text, ""))
+}
+
+// statusLine formats a sloka as "BG 2.47 — text…" bounded to width runes
+func statusLine(s Sloka, abbrev, translation string, width int) string {
+	return truncate(fmt.Sprintf("%s %d.%d — %s", abbrev, s.Chapter, s.Verse, cleanTranslation(translation)), width)
+}
+
+// flagSet reports whether the named flag was given on the command line or
+// in the config file
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// dayKey returns the calendar date of t, e.g. "2024-02-14"
+func dayKey(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+// ANSI styling, cleared at startup when color is disabled
+var (
+	Bold    = "\033[1m"
+	Dim     = "\033[2m"
+	Italic  = "\033[3m"
+	Reverse = "\033[7m"
+	Reset   = "\033[0m"
 )
 
+// styles of the verse blocks with -highlight; change them to restyle a block
+// or set one to "" to leave it plain
+var (
+	SanskritStyle        = Bold
+	TransliterationStyle = Dim + Italic
+	TranslationStyle     = ""
+)
+
+// styles of the verse header and the author line, set by -theme
+var (
+	HeaderStyle = Bold
+	AuthorStyle = Dim
+)
+
+// CommentaryStyle sets -commentary text apart from the translation; unlike
+// the block styles above it is applied without -highlight
+var CommentaryStyle = Italic
+
+// consoleStyling is whether the terminal draws ANSI styling, false on
+// Windows consoles without virtual terminal processing
+var consoleStyling = true
+
+// colorEnabled decides whether to emit ANSI styling: -no-color wins, then
+// -color, then the NO_COLOR convention, then whether output is a terminal
+func colorEnabled(force, disable, toTerminal bool) bool {
+	switch {
+	case disable:
+		return false
+	case force:
+		return true
+	case os.Getenv("NO_COLOR") != "", os.Getenv("TERM") == "dumb":
+		return false
+	}
+	return toTerminal && consoleStyling
+}
+
+// disableColor clears all ANSI styling
+func disableColor() {
+	Bold, Dim, Italic, Reverse, Reset = "", "", "", "", ""
+	SanskritStyle, TransliterationStyle, TranslationStyle, CommentaryStyle = "", "", "", ""
+	HeaderStyle, AuthorStyle = "", ""
+}
+
 func main() {
+	consoleStyling = setupConsole()
+
 	// CLI flags
-	translationSource := flag.String("translation", "siva", "Translation source (siva, purohit, adi, san, tej, chinmay)")
+	translationSource := flag.String("translation", "siva", "Translation source (siva, purohit, adi, san, tej, chinmay), a comma-separated list of them, or all")
 	includeChapter := flag.Bool("chapter-info", false, "Show chapter information")
-	chapterFlag := flag.Int("c", 0, "Specific chapter number (use with -v)")
-	verseFlag := flag.Int("v", 0, "Specific verse number (use with -c)")
+	contextCount := flag.Int("context", 0, "Also show N verses before and after each shown verse, dimmed")
+	chapterSummary := flag.Bool("chapter-summary", false, "Show chapter information with the chapter summary")
+	flag.BoolVar(chapterSummary, "summary", false, "Shorthand for -chapter-summary")
+	chapterOnly := flag.Bool("chapter-only", false, "With -c, show the chapter information (and -summary) without a verse")
+	chapter := new(chapterValue)
+	flag.Var(chapter, "c", "Specific `chapter`, by number or name such as \"karma yoga\" (random verse from it unless -v is given)")
+	chapterFlag := &chapter.number
+	verseFlag := flag.String("v", "", "Specific verse number, a range such as 20-25, or all (use with -c)")
+	idFlag := flag.String("id", "", "Specific verse by its dataset id, e.g. BG2.47")
 	listTranslators := flag.Bool("list-translators", false, "List available translation sources")
-	flag.Parse()
+	prefix := flag.String("prefix", "", "String to print before the output (supports \\n and \\t escapes)")
+	suffix := flag.String("suffix", "", "String to print after the output (supports \\n and \\t escapes)")
+	rotate := flag.Bool("rotate", false, "Show every verse once in shuffled order across runs before repeating")
+	topic := flag.String("topic", "", "Show a random verse on a topic such as equanimity (see -list-topics)")
+	define := flag.String("define", "", "Show the meaning of a Sanskrit term such as dharma and the verses it occurs in")
+	listTerms := flag.Bool("list-terms", false, "List the Sanskrit terms -define knows")
+	compareRef := flag.String("compare", "", "Show the selected verse side by side with another, e.g. gitasay 2.47 -compare 18.66")
+	concordanceWord := flag.String("concordance", "", "List every verse, of -c if given, whose Sanskrit contains a word typed in Devanagari or the -scheme romanization")
+	wordFreq := flag.Bool("word-freq", false, "List the most frequent significant Sanskrit words of each chapter, or of -c; -limit sets how many")
+	listTopics := flag.Bool("list-topics", false, "List the topics -topic accepts with their verse counts")
+	occasionFlag := flag.String("occasion", "", "Show a verse for an occasion such as ekadashi or monday, or auto for today's (see -list-occasions)")
+	listOccasions := flag.Bool("list-occasions", false, "List the occasions -occasion accepts with their next day")
+	favAdd := flag.String("fav-add", "", "Add the verse CHAPTER:VERSE to the favorites")
+	favRm := flag.String("fav-rm", "", "Remove the verse CHAPTER:VERSE from the favorites")
+	reviewFlag := flag.Bool("review", false, "Review the favorites that are due, grading your recall of each from 1 to 5")
+	favList := flag.Bool("fav-list", false, "List the favorite verses")
+	favRandom := flag.Bool("fav-random", false, "Show a random verse from the favorites")
+	noteAdd := flag.String("note-add", "", "Add the -note text, or stdin, as a personal note on the verse CHAPTER:VERSE")
+	noteText := flag.String("note", "", "Text of the note -note-add writes")
+	noteShow := flag.String("note-show", "", "Show the notes on the verse CHAPTER:VERSE")
+	noteRm := flag.String("note-rm", "", "Remove the notes on the verse CHAPTER:VERSE")
+	noteList := flag.Bool("note-list", false, "List the verses with notes and their notes")
+	noNotes := flag.Bool("no-notes", false, "Do not show your notes under the verses")
+	favSync := flag.Bool("fav-sync", false, "Sync the favorites and their reviews with -sync-backend, keeping whichever side changed last")
+	syncBackendFlag := flag.String("sync-backend", SyncGist, "Where -fav-sync keeps the favorites (gist, webdav)")
+	syncURL := flag.String("sync-url", "", "Gist id, or WebDAV file URL, that -fav-sync uses (or set GITASAY_SYNC_URL); a gist is created when empty")
+	syncToken := flag.String("sync-token", "", "GitHub token with the gist scope, or USER:PASSWORD for WebDAV (or set GITASAY_SYNC_TOKEN)")
+	noRepeat := flag.Bool("no-repeat", false, "Avoid random verses already shown until all of them have been")
+	seedFlag := flag.Int64("seed", 0, "Seed for the random selection, to reproduce a pick on any machine")
+	count := flag.Int("n", 1, "Number of distinct random verses to show")
+	daily := flag.Bool("daily", false, "Show the same verse for the whole day")
+	dateFlag := flag.String("date", "", "Day whose -daily or -weekly verse to show, as YYYY-MM-DD (default today)")
+	dailyTZ := flag.String("daily-tz", "", "Time zone for the -daily and -weekly rollover, e.g. Asia/Kolkata (default local)")
+	weekly := flag.Bool("weekly", false, "Show the same verse for the whole ISO week")
+	stripTags := flag.Bool("strip-html", true, "Remove HTML tags and entities from translation text when present")
+	listSources := flag.Bool("list-sources", false, "List translation sources with their language and author")
+	jsonOutput := flag.Bool("json", false, "Print machine-readable JSON output")
+	wrapMode := flag.String("wrap", "greedy", "Line wrapping algorithm (greedy, balanced)")
+	readRange := flag.String("read", "", "Read every verse of a chapter range in order, e.g. 1-3")
+	status := flag.Bool("status", false, "Print a single plain line for status bars such as tmux")
+	statusWidth := flag.Int("status-width", 60, "Maximum width of the -status line")
+	oneline := flag.Bool("oneline", false, "Print the -status line without a trailing newline, for prompts such as starship")
+	bilingual := flag.Bool("bilingual", false, "Show an English and a Hindi translation side by side")
+	lengthStats := flag.Bool("translation-stats", false, "Print translation length statistics per source")
+	format := flag.String("format", FormatAuto, "Output format of the verse (auto, text, plain, json, yaml, md); auto is text, styled only on a terminal")
+	highlight := flag.Bool("highlight", false, "Style the Sanskrit, transliteration and translation distinctly")
+	scheme := flag.String("scheme", "", "Transliteration scheme (iast, itrans, hk, slp1); default: the dataset's")
+	themeFlag := flag.String("theme", DefaultTheme, "Color theme (default, mono, saffron, solarized)")
+	cite := flag.String("cite", CiteNone, "Citation printed after the translation (short, long, none)")
+	usageFlag := flag.Bool("usage-stats", false, "Print your streak, the verses viewed and the most viewed chapters")
+	noTrack := flag.Bool("no-track", false, "Do not count the shown verses in the -usage-stats")
+	dataStats := flag.Bool("stats", false, "Print a summary of the dataset: verse counts and per-source coverage")
+	plainHeader := flag.Bool("plain-header", false, "Print chapter and verse headers without styling")
+	autoSource := flag.String("auto-source", "", "Pick the translation source per verse (longest, en, hi)")
+	hyphens := flag.Bool("hyphenate", false, "Hyphenate words longer than the line width instead of overflowing")
+	justified := flag.Bool("justify", false, "Justify wrapped lines to the full width, except the last line of each sentence")
+	allTranslations := flag.Bool("include-all-translations", false, "With -json, include every source's translation")
+	search := flag.String("search", "", "List verses whose translation contains the given term")
+	lang := flag.String("lang", "en", "Language of chapter names and meanings (en, hi)")
+	listChapters := flag.Bool("list-chapters", false, "List all chapters")
+	outputPath := flag.String("output", "", "Write the output to a file instead of stdout")
+	flag.StringVar(outputPath, "o", "", "Shorthand for -output")
+	buffered := flag.Bool("buffered", false, "Render all output in memory and write it only if complete")
+	plan := flag.Bool("plan", false, "Print a reading plan as CSV (or JSON with -json)")
+	planStart := flag.String("start", "", "First day of the -plan or -plan-start as YYYY-MM-DD (default today)")
+	perDay := flag.Int("per-day", 10, "Verses per day in the -plan")
+	planBegin := flag.Bool("plan-start", false, "Start a reading plan of every verse over -days days, replacing any current one")
+	planDays := flag.Int("days", 90, "Length of the -plan-start reading plan in days, or of the -feed (default 30)")
+	feed := flag.Bool("feed", false, "Write an Atom or RSS feed of the -daily verses of the past -days days")
+	feedFormat := flag.String("feed-format", "atom", "Format of the -feed (atom, rss)")
+	feedURL := flag.String("feed-url", "", "Address the -feed is published at, for its links and ids")
+	planToday := flag.Bool("plan-today", false, "Show today's verses of the reading plan and mark them read")
+	planStatus := flag.Bool("plan-status", false, "Show the progress and streak of the reading plan")
+	allSources := flag.Bool("all-translations", false, "Show every available translation of the verse")
+	blind := flag.Bool("blind", false, "With -all-translations, hide authors behind shuffled labels")
+	reveal := flag.Bool("reveal", false, "With -blind, print which author each label stands for")
+	searchIn := flag.String("search-in", "translation", "Comma-separated fields for -search (translation, translations, sanskrit, transliteration, or a source such as tej)")
+	limit := flag.Int("limit", 0, "Maximum number of -search or -concordance results (0 for all), or of -word-freq words per chapter")
+	searchRandom := flag.Bool("search-random", false, "With -search, show one random matching verse in full")
+	widthFlag := flag.Int("width", 0, "Line width for wrapping (default: terminal width, else $COLUMNS or 70)")
+	noColor := flag.Bool("no-color", false, "Disable colors and styling")
+	noPager := flag.Bool("no-pager", false, "Print long output directly instead of through $PAGER")
+	forceColor := flag.Bool("color", false, "Force colors and styling even when not printing to a terminal")
+	dataSource := flag.String("source", "embedded", "Where the shown verses come from (embedded, api); api falls back to embedded")
+	apiURL := flag.String("api-url", gita.DefaultAPIURL, "Base URL of the Bhagavad Gita API for -source api")
+	apiTimeout := flag.Duration("api-timeout", 5*time.Second, "Timeout of each -source api request")
+	cacheTTL := flag.Duration("cache-ttl", defaultCacheTTL, "How long -source api verses and -audio recitations are used from the cache before they are fetched again; older ones still stand in when fetching fails")
+	cacheStatus := flag.Bool("cache-status", false, "Show where the cache is and how much it holds")
+	cacheClear := flag.Bool("cache-clear", false, "Remove the cached verses and recitations")
+	strict := flag.Bool("strict", false, "Exit with status 4 instead of falling back to the embedded data when -data or -source api fails")
+	textName := flag.String("text", "gita", "Scripture to show: "+strings.Join(textNames(), ", "))
+	dataFlag := flag.String("data", "", "Path to a gita.json to use instead of the embedded data (or set GITASAY_DATA)")
+	doctor := flag.Bool("doctor", false, "Check the dataset in use for problems, such as chapters whose verses_count is wrong or missing translations")
+	dataMerge := flag.Bool("data-merge", false, "Lay the -data file over the embedded data, replacing only the verses and fields it lists")
+	updateDataFlag := flag.Bool("update-data", false, "Download the latest curated gita.json from the releases, to be used instead of the embedded data")
+	updateURL := flag.String("update-url", defaultDataURL, "URL -update-data downloads the dataset from, with its SHA-256 sum at the URL plus .sha256 (or set GITASAY_UPDATE_URL)")
+	cow := flag.Bool("cow", false, "Show the verse in a cowsay-style speech bubble")
+	figure := flag.String("figure", "cow", "Figure drawn below the -cow bubble (see -list-figures)")
+	center := flag.Bool("center", false, "Center the verse horizontally in the terminal")
+	box := flag.Bool("box", false, "Draw a frame around the verse")
+	boxStyle := flag.String("box-style", "rounded", "Frame drawn by -box (ascii, double, heavy, rounded, single)")
+	listFigures := flag.Bool("list-figures", false, "List the figures available to -figure")
+	markdown := flag.Bool("md", false, "Print the verse as Markdown")
+	commentary := flag.Bool("commentary", false, "Show the commentary of the translation source, where available")
+	commentaryOnly := flag.Bool("commentary-only", false, "Show only the commentary of the translation source, without the verse")
+	wordMeanings := flag.Bool("word-meanings", false, "Show the meaning of each Sanskrit word under the verse")
+	split := flag.Bool("split", false, "Show each Sanskrit line with its sandhi resolved into separate words (pada-patha) under it, derived from the word glosses unless the data has a padapatha")
+	related := flag.Bool("related", false, "List the verses related in theme to each shown verse, with their first words")
+	animateFlag := flag.Bool("animate", false, "On a terminal, type the verse out and fade the translation in; Ctrl-C shows the rest at once")
+	animateDelay := flag.Duration("animate-delay", defaultAnimateDelay, "Pause after each character -animate types")
+	commitMsg := flag.Bool("commit-msg", false, "Print a short, plain, cited one-line verse for commit messages")
+	botFlag := flag.Bool("bot", false, "Post the verse to the chat webhook of -bot-url")
+	botURL := flag.String("bot-url", "", "Slack or Discord webhook URL, or Telegram sendMessage URL, for -bot; default: $GITASAY_BOT_URL")
+	botPlatform := flag.String("bot-platform", "", "Chat platform of -bot-url (slack, discord, telegram); default: detected from the URL")
+	botChat := flag.String("bot-chat", "", "Telegram chat id or @channel the -bot posts to")
+	botRetries := flag.Int("bot-retries", 2, "How many times -bot retries a failed post")
+	dryRun := flag.Bool("dry-run", false, "With -bot, print the request instead of posting it")
+	hookInstall := flag.String("hook-install", "", "Install a git hook in the current repository: prepare-commit-msg adds a verse to the message being edited, post-commit prints one after each commit")
+	hookUninstall := flag.String("hook-uninstall", "", "Remove a git hook installed by -hook-install (prepare-commit-msg or post-commit)")
+	force := flag.Bool("force", false, "With -hook-install, replace an existing hook gitasay did not write; with -fav-sync, push the local favorites over the synced ones")
+	completion := flag.String("completion", "", "Print a shell completion script (bash, zsh, fish)")
+	selectFlag := flag.String("select", SelectRandom, "How to pick the verse from the book or the -c chapter (random, shortest, longest, first, last, popular)")
+	chaptersFlag := flag.String("chapters", "", "Comma-separated chapters or ranges, such as 2,12,18 or 2-6, to draw random verses from")
+	imagePath := flag.String("image", "", "Write the verse as a quote card to `FILE` (.svg, or .png with rsvg-convert, ImageMagick or Inkscape)")
+	imageBackground := flag.String("image-bg", "#fdf6e3", "Background color of the -image card (#rrggbb)")
+	imageForeground := flag.String("image-fg", "#3b2f2f", "Text color of the -image card (#rrggbb)")
+	wallpaperPath := flag.String("wallpaper", "", "Write the verse centered on a desktop wallpaper to `FILE` (.svg or .png), in the -image-bg and -image-fg colors")
+	resolution := flag.String("resolution", "1920x1080", "Size of the -wallpaper in pixels, as WIDTHxHEIGHT")
+	wallpaperImage := flag.String("wallpaper-image", "", "Picture (.png, .jpg or .svg) to set the -wallpaper text over")
+	setWallpaperFlag := flag.Bool("set-wallpaper", false, "Make the -wallpaper the desktop wallpaper (osascript on macOS, gsettings or feh on Linux)")
+	audio := flag.Bool("audio", false, "After showing the verse, play its recitation from -audio-url, downloading it once into the cache")
+	audioDownload := flag.Bool("audio-download", false, "Download the recitation of the verse into the cache and print its path instead of playing it")
+	speakFlag := flag.Bool("speak", false, "After showing the verse, read its translation aloud with the platform's text-to-speech, or -speak-url")
+	voice := flag.String("voice", "", "Voice -speak reads in, as the speech tool names it (e.g. Samantha for say, en-gb or hi for espeak)")
+	speakRate := flag.Int("speak-rate", 0, "Speed of -speak in words per minute (0 for the voice's default)")
+	speakURL := flag.String("speak-url", "", "Speech service -speak posts the text to instead, which returns audio (or set GITASAY_SPEAK_URL)")
+	audioTemplate := flag.String("audio-url", "", "URL of the recitations with {chapter} and {verse} placeholders (or set GITASAY_AUDIO_URL)")
+	copyFlag := flag.Bool("copy", false, "Also copy the verse, without styling, to the clipboard")
+	copyFormat := flag.String("copy-format", CopyVerse, "What -copy puts on the clipboard (verse, translation)")
+	plain := flag.Bool("plain", false, "Print unwrapped, unstyled text for tools that wrap it themselves")
+	serve := flag.Bool("serve", false, "Serve verses as a JSON HTTP API instead of printing one")
+	host := flag.String("host", "localhost", "Host address -serve listens on")
+	port := flag.Int("port", 8080, "Port -serve listens on (1965 with -protocol gemini)")
+	protocol := flag.String("protocol", ProtocolHTTP, "Protocol -serve speaks: http for the JSON API, or gemini for a capsule of gemtext pages")
+	tlsCert := flag.String("tls-cert", "", "Certificate file of -protocol gemini (default a self-signed one kept in the state directory)")
+	tlsKey := flag.String("tls-key", "", "Key file of the -tls-cert certificate")
+	notify := flag.Bool("notify", false, "Keep running and post a random verse as a desktop notification -every interval or daily -at a time")
+	notifyEvery := flag.Duration("every", 0, "Interval between -notify notifications, e.g. 4h")
+	notifyAt := flag.String("at", "", "Time of day of the daily -notify notification, as HH:MM")
+	stdinFlag := flag.Bool("stdin", false, "Show the verses cited on stdin, one per line such as 2.47, 2.20-25 or BG 18:66")
+	pickFlag := flag.Bool("pick", false, "Choose the verse from a fuzzy-searchable list of the book, the -c chapter or a topic, with fzf when installed")
+	quizFlag := flag.Bool("quiz", false, "Quiz yourself on random verses, -n of them (10 by default), from the book or the -c chapter")
+	quizMode := flag.String("quiz-mode", QuizReference, "Kind of quiz question (reference, choice, recall)")
+	interactive := flag.Bool("interactive", false, "Browse verses, switch translations and search with single keys")
+	flag.BoolVar(interactive, "browse", false, "Shorthand for -interactive")
+	flag.BoolVar(interactive, "tui", false, "Shorthand for -interactive")
+	exportFormat := flag.String("export", "", "Export the whole book, or the -c chapter, in order (txt, json, md, html, epub, fortune)")
+	strfile := flag.Bool("strfile", false, "With -export fortune and -output, also write the strfile index FILE.dat")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	parseArgs(os.Args[1:])
 
-	// validate translation source
-	validSources := []string{Siva, Purohit, Adi, San, Tej, Chinmay}
-	validSource := false
-	for _, source := range validSources {
-		if *translationSource == source {
-			validSource = true
-			break
+	// apply defaults from the config file for flags not given
+	if path, err := configPath(); err == nil {
+		config, err := loadConfig(path)
+		if err == nil {
+			err = applyConfig(flag.CommandLine, config)
 		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid config file %s: %v\n", path, err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	// print build metadata if requested
+	if *showVersion {
+		fmt.Printf("gitasay %s (commit %s, built %s)\n", version, commit, date)
+		os.Exit(0)
+	}
+
+	// map the output format onto the flags that implement it; -json, -md
+	// and -plain pick their format whatever -format says
+	var yamlOutput bool
+	verseFormatName := *format
+	switch {
+	case *jsonOutput:
+		verseFormatName = FormatJSON
+	case *markdown:
+		verseFormatName = FormatMarkdown
+	case *plain:
+		verseFormatName = FormatPlain
+	case *format == FormatAuto:
+		verseFormatName = FormatText
 	}
-	if !validSource {
-		fmt.Printf("Invalid translation source: %s\n", *translationSource)
-		fmt.Println("Valid sources: siva, purohit, adi, san, tej, chinmay")
-		os.Exit(1)
+	switch *format {
+	case FormatAuto, FormatText:
+	case FormatPlain:
+		*plain = true
+	case FormatJSON:
+		*jsonOutput = true
+	case FormatYAML:
+		yamlOutput = true
+	case FormatMarkdown:
+		*markdown = true
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid output format: %s\n", *format)
+		fmt.Fprintf(os.Stderr, "Valid formats: %s\n", strings.Join(outputFormats, ", "))
+		os.Exit(exitUsage)
 	}
 
-	// read embedded JSON file
-	data, err := gitaFS.ReadFile("gita.json")
-	if err != nil {
-		fmt.Printf("Error reading embedded data: %v\n", err)
-		os.Exit(1)
+	// validate and apply the color theme
+	t, ok := themes[*themeFlag]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid theme: %s\n", *themeFlag)
+		fmt.Fprintf(os.Stderr, "Valid themes: %s\n", strings.Join(themeNames(), ", "))
+		os.Exit(exitUsage)
+	}
+	if err := applyTheme(t); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid theme %s: %v\n", *themeFlag, err)
+		os.Exit(exitUsage)
+	}
+	// a theme other than the default styles the verse blocks too
+	if *themeFlag != DefaultTheme {
+		*highlight = true
 	}
 
-	// parse JSON into structs
-	var allSlokas AllSlokas
-	err = json.Unmarshal(data, &allSlokas)
-	if err != nil {
-		fmt.Printf("Error parsing JSON: %v\n", err)
-		os.Exit(1)
+	// disable styling when not wanted
+	styled := true
+	if *jsonOutput || yamlOutput || *plain || *exportFormat == "json" || *exportFormat == "fortune" || !colorEnabled(*forceColor, *noColor, *outputPath == "" && isTerminal(os.Stdout)) {
+		disableColor()
+		styled = false
 	}
 
-	var selectedSloka Sloka
+	// list the -figure drawings if requested
+	if *listFigures {
+		for _, name := range figureNames() {
+			fmt.Println(name)
+		}
+		return
+	}
 
-	// if specific verse requested
-	if *chapterFlag > 0 && *verseFlag > 0 {
-		found := false
-		for _, sloka := range allSlokas.Slokas {
-			if sloka.Chapter == *chapterFlag && sloka.Verse == *verseFlag {
-				selectedSloka = sloka
-				found = true
-				break
+	// show translation source details if requested
+	if *listSources {
+		if *jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(translators); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			for _, t := range translators {
+				fmt.Printf("%-8s %-3s %s\n", t.Key, t.Lang, t.Author)
 			}
 		}
-		if !found {
-			fmt.Printf("Chapter %d, Verse %d not found.\n", *chapterFlag, *verseFlag)
-			os.Exit(1)
-		}
-	} else {
-		// pick random sloka
-		rand.Seed(time.Now().UnixNano())
-		if len(allSlokas.Slokas) == 0 {
-			fmt.Println("No slokas found in the JSON data.")
-			os.Exit(1)
+		os.Exit(0)
+	}
+
+	// "all" or a comma-separated list compares several sources
+	var compared []string
+	if *translationSource == "all" {
+		*allSources = true
+		*translationSource = Siva
+	} else if strings.Contains(*translationSource, ",") {
+		for _, source := range strings.Split(*translationSource, ",") {
+			if source = strings.TrimSpace(source); !isSource(source) {
+				fmt.Fprintf(os.Stderr, "Invalid translation source: %s\n", source)
+				if s := closest(source, sourceKeys()); s != "" {
+					fmt.Fprintf(os.Stderr, "Did you mean %s?\n", s)
+				}
+				fmt.Fprintln(os.Stderr, "Valid sources: siva, purohit, adi, san, tej, chinmay")
+				os.Exit(exitUsage)
+			}
+			compared = append(compared, source)
 		}
-		selectedSloka = allSlokas.Slokas[rand.Intn(len(allSlokas.Slokas))]
+		*allSources = true
+		*translationSource = compared[0]
 	}
 
-	fmt.Println()
+	// validate translation source
+	validSources := []string{Siva, Purohit, Adi, San, Tej, Chinmay}
+	if !slices.Contains(validSources, *translationSource) {
+		fmt.Fprintf(os.Stderr, "Invalid translation source: %s\n", *translationSource)
+		if s := closest(*translationSource, validSources); s != "" {
+			fmt.Fprintf(os.Stderr, "Did you mean %s?\n", s)
+		}
+		fmt.Fprintln(os.Stderr, "Valid sources: siva, purohit, adi, san, tej, chinmay")
+		os.Exit(exitUsage)
+	}
 
-	// show list of available translators if requested
-	if *listTranslators {
-		fmt.Println("Available translation sources:")
-		for _, source := range validSources {
-			fmt.Printf(" - %s\n", source)
+	// validate language, which the locale sets when -lang is not given
+	if !flagSet("lang") {
+		if language, ok := envLang(); ok {
+			*lang = language
 		}
-		os.Exit(0)
 	}
+	language, ok := parseLang(*lang)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid language: %s\n", *lang)
+		fmt.Fprintln(os.Stderr, "Valid languages: en, hi")
+		os.Exit(exitUsage)
+	}
+	*lang = language
+	uiLang = language
 
-	// show chapter info if requested
-	if *includeChapter {
-		for _, chapter := range allSlokas.Chapters {
-			if chapter.ChapterNumber == selectedSloka.Chapter {
-				fmt.Printf("%sChapter %d: %s%s\n", Bold, chapter.ChapterNumber, chapter.Name, Reset)
-				if chapter.Translation != "" {
-					fmt.Printf("(%s)\n", chapter.Translation)
-				}
-				if chapter.Meaning.En != "" {
-					fmt.Println(wrapText("Meaning: " + chapter.Meaning.En))
+	// -lang hi picks a Hindi translation unless one is named; a named
+	// translation must be in the requested language
+	if flagSet("lang") && !*allSources && *autoSource == "" && !*bilingual {
+		switch {
+		case !flagSet("translation") && sourceLang(*translationSource) != *lang:
+			if *lang == "hi" {
+				*translationSource = Tej
+			} else {
+				*translationSource = Siva
+			}
+		case sourceLang(*translationSource) != *lang:
+			fmt.Fprintf(os.Stderr, "Translation source %s is not in language %s\n", *translationSource, *lang)
+			var keys []string
+			for _, t := range translators {
+				if t.Lang == *lang {
+					keys = append(keys, t.Key)
 				}
-				fmt.Println()
-				break
 			}
+			fmt.Fprintf(os.Stderr, "Sources in %s: %s\n", *lang, strings.Join(keys, ", "))
+			os.Exit(exitUsage)
 		}
 	}
 
-	// display chapter and verse header
-	fmt.Printf("%sChapter %d, Verse %d%s\n\n", Bold, selectedSloka.Chapter, selectedSloka.Verse, Reset)
+	// validate source heuristic
+	switch *autoSource {
+	case "", "longest", "en", "hi":
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid source heuristic: %s\n", *autoSource)
+		fmt.Fprintln(os.Stderr, "Valid heuristics: longest, en, hi")
+		os.Exit(exitUsage)
+	}
 
-	// print sanskrit
-	sanskritLines := strings.Split(selectedSloka.Slok, "\n")
-	for _, line := range sanskritLines {
-		if strings.TrimSpace(line) != "" {
-			fmt.Println(wrapText(strings.TrimSpace(line)))
+	// resolve the day and time zone of daily and weekly rollovers
+	now := time.Now()
+	if *dailyTZ != "" {
+		loc, err := time.LoadLocation(*dailyTZ)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid time zone: %s\n", *dailyTZ)
+			os.Exit(exitUsage)
+		}
+		now = now.In(loc)
+	}
+	if *dateFlag != "" {
+		day, err := time.ParseInLocation("2006-01-02", *dateFlag, now.Location())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid date %q: use YYYY-MM-DD\n", *dateFlag)
+			os.Exit(exitUsage)
 		}
+		now = day
 	}
-	fmt.Println()
 
-	// print transliteration
-	transLines := strings.Split(selectedSloka.Transliteration, ".")
-	for _, line := range transLines {
-		if strings.TrimSpace(line) != "" {
-			fmt.Println(wrapText(strings.TrimSpace(line)))
+	// select wrapping algorithm
+	var wrap func(string, int) string
+	switch *wrapMode {
+	case "greedy":
+		wrap = wrapText
+	case "balanced":
+		wrap = wrapBalanced
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid wrap algorithm: %s\n", *wrapMode)
+		fmt.Fprintln(os.Stderr, "Valid algorithms: greedy, balanced")
+		os.Exit(exitUsage)
+	}
+	if *plain {
+		wrap = unwrapped
+	} else {
+		if *hyphens {
+			base := wrap
+			wrap = func(text string, width int) string { return base(hyphenate(text, width), width) }
+		}
+		if *justified {
+			base := wrap
+			wrap = func(text string, width int) string { return justify(base(text, width), width) }
 		}
 	}
-	fmt.Println()
 
-	// pick translation text and author
-	var translationText, author string
-	switch *translationSource {
-	case Siva:
-		translationText = selectedSloka.Siva.Et
-		author = selectedSloka.Siva.Author
-	case Purohit:
-		translationText = selectedSloka.Purohit.Et
-		author = selectedSloka.Purohit.Author
-	case Adi:
-		translationText = selectedSloka.Adi.Et
-		author = selectedSloka.Adi.Author
-	case San:
-		translationText = selectedSloka.San.Et
-		author = selectedSloka.San.Author
-	case Tej:
-		translationText = selectedSloka.Tej.Ht
-		author = selectedSloka.Tej.Author
-	case Chinmay:
-		translationText = selectedSloka.Chinmay.Hc
-		author = selectedSloka.Chinmay.Author
+	// pick line width
+	width := *widthFlag
+	if width == 0 {
+		width = terminalWidth()
+		if *center {
+			// a full-width verse has nothing to center
+			width = min(width, centerWidth)
+		}
+	}
+	if *cow {
+		// leave room for the bubble border
+		width -= bubbleMargin
+	}
+	if *box {
+		width -= boxMargin
+	}
+	if width < minWidth {
+		width = minWidth
 	}
 
-	// print translation
-	fmt.Println(wrapText(translationText))
-	fmt.Printf("%s(%s)%s\n", Dim, author, Reset)
+	// validate figure
+	if _, ok := figures[*figure]; !ok {
+		fmt.Fprintf(os.Stderr, "Invalid figure: %s\n", *figure)
+		fmt.Fprintf(os.Stderr, "Valid figures: %s\n", strings.Join(figureNames(), ", "))
+		os.Exit(exitUsage)
+	}
+
+	// validate box style
+	if _, ok := boxStyles[*boxStyle]; !ok {
+		fmt.Fprintf(os.Stderr, "Invalid box style: %s\n", *boxStyle)
+		fmt.Fprintf(os.Stderr, "Valid styles: %s\n", strings.Join(boxStyleNames(), ", "))
+		os.Exit(exitUsage)
+	}
+
+	// validate citation style
+	if !slices.Contains(citeStyles, *cite) {
+		fmt.Fprintf(os.Stderr, "Invalid citation style: %s\n", *cite)
+		fmt.Fprintf(os.Stderr, "Valid styles: %s\n", strings.Join(citeStyles, ", "))
+		os.Exit(exitUsage)
+	}
+
+	// validate selection strategy
+	if !slices.Contains(selectStrategies, *selectFlag) {
+		fmt.Fprintf(os.Stderr, "Invalid selection strategy: %s\n", *selectFlag)
+		fmt.Fprintf(os.Stderr, "Valid strategies: %s\n", strings.Join(selectStrategies, ", "))
+		os.Exit(exitUsage)
+	}
+
+	// validate export format
+	if *exportFormat != "" && !slices.Contains(exportFormats, *exportFormat) {
+		fmt.Fprintf(os.Stderr, "Invalid export format: %s\n", *exportFormat)
+		fmt.Fprintf(os.Stderr, "Valid formats: %s\n", strings.Join(exportFormats, ", "))
+		os.Exit(exitUsage)
+	}
+
+	// an epub is a zip archive, which a terminal cannot show
+	if *exportFormat == "epub" && *outputPath == "" && isTerminal(os.Stdout) {
+		fmt.Fprintln(os.Stderr, "Invalid flags: -export epub writes a binary file; give it with -output, e.g. -o gita.epub")
+		os.Exit(exitUsage)
+	}
+	// the index is written next to the fortune file
+	if *strfile && (*exportFormat != "fortune" || *outputPath == "") {
+		fmt.Fprintln(os.Stderr, "Invalid flags: -strfile needs -export fortune and -output")
+		os.Exit(exitUsage)
+	}
+	if *copyFormat != CopyVerse && *copyFormat != CopyTranslation {
+		fmt.Fprintf(os.Stderr, "Invalid copy format: %s\n", *copyFormat)
+		fmt.Fprintf(os.Stderr, "Valid copy formats: %s, %s\n", CopyVerse, CopyTranslation)
+		os.Exit(exitUsage)
+	}
+	if *audioTemplate == "" {
+		*audioTemplate = os.Getenv("GITASAY_AUDIO_URL")
+	}
+	if (*setWallpaperFlag || *wallpaperImage != "") && *wallpaperPath == "" {
+		fmt.Fprintln(os.Stderr, "Invalid flags: -set-wallpaper and -wallpaper-image need -wallpaper FILE")
+		os.Exit(exitUsage)
+	}
+	if *statusWidth < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid status width: %d (must be at least 1)\n", *statusWidth)
+		os.Exit(exitUsage)
+	}
+	if *speakRate < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid speak rate: %d (must not be negative)\n", *speakRate)
+		os.Exit(exitUsage)
+	}
+	var audioCache *httpCache // where the recitations are downloaded
+	if *audio || *audioDownload {
+		if _, err := audioURL(*audioTemplate, Sloka{Chapter: 1, Verse: 1}); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Set -audio-url or GITASAY_AUDIO_URL, e.g. https://example.org/gita/{chapter}/{verse}.mp3")
+			os.Exit(exitUsage)
+		}
+		var err error
+		if audioCache, err = newHTTPCache(*cacheTTL); err != nil {
+			fmt.Fprintf(os.Stderr, "Error locating the cache: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	// validate data source
+	if *dataSource != "embedded" && *dataSource != "api" {
+		fmt.Fprintf(os.Stderr, "Invalid data source: %s\n", *dataSource)
+		fmt.Fprintln(os.Stderr, "Valid sources: embedded, api")
+		os.Exit(exitUsage)
+	}
+
+	// validate text
+	text, ok := gita.Lookup(*textName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid text: %s\n", *textName)
+		fmt.Fprintf(os.Stderr, "Valid texts: %s\n", strings.Join(textNames(), ", "))
+		os.Exit(exitUsage)
+	}
+	if *dataSource == "api" && text.Name != "gita" {
+		fmt.Fprintf(os.Stderr, "Invalid flags: -source api serves only the Bhagavad Gita, not -text %s\n", text.Name)
+		os.Exit(exitUsage)
+	}
+
+	// validate transliteration scheme
+	if *scheme != "" && !slices.Contains(schemes, *scheme) {
+		fmt.Fprintf(os.Stderr, "Invalid transliteration scheme: %s\n", *scheme)
+		fmt.Fprintf(os.Stderr, "Valid schemes: %s\n", strings.Join(schemes, ", "))
+		os.Exit(exitUsage)
+	}
+
+	// the summary is part of the chapter information
+	if *chapterSummary {
+		*includeChapter = true
+	}
+
+	// show or clear the cache if requested
+	if *cacheStatus || *cacheClear {
+		cache, err := newHTTPCache(*cacheTTL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error locating the cache: %v\n", err)
+			os.Exit(exitError)
+		}
+		if *cacheClear {
+			st, err := cache.clear()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error clearing the cache: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Printf("Removed %d files (%s) from %s\n", st.Files, byteSize(st.Bytes), st.Dir)
+			os.Exit(0)
+		}
+		st, err := cache.status()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the cache: %v\n", err)
+			os.Exit(exitError)
+		}
+		if *jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(st); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printCacheStatus(os.Stdout, st)
+		}
+		os.Exit(0)
+	}
+
+	// install or remove a git hook if requested
+	if kind := firstNonEmpty(*hookInstall, *hookUninstall); kind != "" {
+		if !slices.Contains(hookTypes, kind) {
+			fmt.Fprintf(os.Stderr, "Invalid hook: %s\n", kind)
+			fmt.Fprintf(os.Stderr, "Valid hooks: %s\n", strings.Join(hookTypes, ", "))
+			os.Exit(exitUsage)
+		}
+		if *hookInstall != "" {
+			path, err := installHook(kind, hookArgs(), *force)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error installing hook: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Printf("Installed %s\n", path)
+		} else {
+			path, err := uninstallHook(kind)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error removing hook: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Printf("Removed %s\n", path)
+		}
+		os.Exit(0)
+	}
+
+	// sync the favorites if requested
+	if *favSync {
+		if !slices.Contains(syncBackends, *syncBackendFlag) {
+			fmt.Fprintf(os.Stderr, "Invalid sync backend: %s\n", *syncBackendFlag)
+			fmt.Fprintf(os.Stderr, "Valid backends: %s\n", strings.Join(syncBackends, ", "))
+			os.Exit(exitUsage)
+		}
+		location := firstNonEmpty(*syncURL, os.Getenv("GITASAY_SYNC_URL"))
+		backend, err := newSyncBackend(*syncBackendFlag, location, firstNonEmpty(*syncToken, os.Getenv("GITASAY_SYNC_TOKEN")))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		msg, err := syncFavorites(backend, *force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error syncing favorites: %v\n", err)
+			os.Exit(exitError)
+		}
+		fmt.Println(msg)
+		if g, ok := backend.(*gistBackend); ok && location == "" {
+			fmt.Printf("Sync the other machines with it by setting GITASAY_SYNC_URL=%s\n", g.id)
+		}
+		os.Exit(0)
+	}
 
-	fmt.Println()
+	// download the latest dataset if requested
+	if *updateDataFlag {
+		path, err := updatedDataPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating data: %v\n", err)
+			os.Exit(exitError)
+		}
+		rawURL := *updateURL
+		if !flagSet("update-url") {
+			rawURL = firstNonEmpty(os.Getenv("GITASAY_UPDATE_URL"), rawURL)
+		}
+		book, changed, err := updateData(rawURL, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating data: %v\n", err)
+			os.Exit(exitError)
+		}
+		if changed {
+			fmt.Printf("Saved %d verses to %s\n", len(book.Data().Slokas), path)
+		} else {
+			fmt.Printf("%s is up to date.\n", path)
+		}
+		os.Exit(0)
+	}
+
+	// load an external dataset of the text when configured, then the one
+	// update-data downloaded, else the embedded one
+	dataPath := *dataFlag
+	if dataPath == "" {
+		dataPath = os.Getenv("GITASAY_DATA")
+	}
+	if dataPath == "" && text.Name == "gita" {
+		if path, err := updatedDataPath(); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				dataPath = path
+			}
+		}
+	}
+	// check the dataset instead of showing verses if requested
+	if *doctor {
+		var report DoctorReport
+		if dataPath != "" && !*dataMerge {
+			data, err := os.ReadFile(dataPath)
+			if err != nil {
+				fail(*jsonOutput, exitData, fmt.Sprintf("Error reading %s: %v", dataPath, err))
+			}
+			report = checkDatasetFile(dataPath, data)
+		} else {
+			book, err := gita.Open(text.Name)
+			name := "embedded " + text.Name
+			if err == nil && dataPath != "" {
+				book, err = loadMerged(dataPath, text.Name)
+				name = fmt.Sprintf("%s merged over the embedded %s", dataPath, text.Name)
+			}
+			if err != nil {
+				fail(*jsonOutput, exitData, fmt.Sprintf("Error loading %s: %v", firstNonEmpty(dataPath, name), err))
+			}
+			report = DoctorReport{Dataset: name, Translations: []TranslationCount{}, Problems: []DoctorProblem{}}
+			checkDataset(&report, book.Data())
+		}
+		if *jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printDoctorReport(os.Stdout, report)
+		}
+		if report.Errors > 0 {
+			os.Exit(exitData)
+		}
+		os.Exit(0)
+	}
+
+	var book *gita.Gita
+	var err error
+	if dataPath != "" {
+		if *dataMerge {
+			book, err = loadMerged(dataPath, text.Name)
+		} else if book, err = gita.LoadFile(dataPath); err == nil {
+			book.Text = text
+		}
+		if err != nil && *strict {
+			fail(*jsonOutput, exitData, fmt.Sprintf("Error loading %s: %v", dataPath, err))
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v; using embedded data\n", dataPath, err)
+		}
+	}
+	if dataPath == "" || err != nil {
+		book, err = gita.Open(text.Name)
+		if err != nil {
+			fail(*jsonOutput, exitData, fmt.Sprintf("Error loading embedded data: %v", err))
+		}
+	}
+	allSlokas := book.Data()
+
+	// look the -c chapter up by name
+	if chapter.name != "" {
+		switch found := findChapter(allSlokas.Chapters, chapter.name); len(found) {
+		case 0:
+			notFound(*jsonOutput, msgf("No chapter named %q; 'gitasay chapters' lists their names.", chapter.name))
+		case 1:
+			*chapterFlag = found[0].ChapterNumber
+		default:
+			var numbers []string
+			for _, c := range found {
+				numbers = append(numbers, strconv.Itoa(c.ChapterNumber))
+			}
+			notFound(*jsonOutput, msgf("%q names chapters %s; give more of the name or the number.", chapter.name, strings.Join(numbers, ", ")))
+		}
+	}
+
+	// print a shell completion script if requested
+	if *completion != "" {
+		var chapters []string
+		verseCounts := make([]int, len(allSlokas.Chapters))
+		for _, c := range allSlokas.Chapters {
+			chapters = append(chapters, strconv.Itoa(c.ChapterNumber))
+			if c.ChapterNumber >= 1 && c.ChapterNumber <= len(verseCounts) {
+				verseCounts[c.ChapterNumber-1] = c.VersesCount
+			}
+		}
+		var sources []string
+		for _, t := range translators {
+			sources = append(sources, t.Key)
+		}
+		var commands []string
+		for _, c := range subcommands {
+			commands = append(commands, c.name)
+		}
+		script, err := completionScript(*completion, flag.CommandLine, map[string][]string{
+			"translation":    sources,
+			"c":              chapters,
+			"read":           chapters,
+			"lang":           {"en", "hi"},
+			"wrap":           {"greedy", "balanced"},
+			"protocol":       serveProtocols,
+			"auto-source":    {"longest", "en", "hi"},
+			"search-in":      append([]string{FieldTranslation, FieldTranslations, FieldSanskrit, FieldTransliteration}, sources...),
+			"box-style":      boxStyleNames(),
+			"figure":         figureNames(),
+			"completion":     {"bash", "zsh", "fish"},
+			"export":         exportFormats,
+			"select":         selectStrategies,
+			"cite":           citeStyles,
+			"theme":          themeNames(),
+			"topic":          gita.Topics(),
+			"occasion":       append([]string{occasionAuto}, occasionNames()...),
+			"scheme":         schemes,
+			"quiz-mode":      quizModes,
+			"text":           textNames(),
+			"feed-format":    feedFormats,
+			"define":         gita.Terms(),
+			"hook-install":   hookTypes,
+			"sync-backend":   syncBackends,
+			"bot-platform":   botPlatforms,
+			"hook-uninstall": hookTypes,
+			"copy-format":    {CopyVerse, CopyTranslation},
+			"source":         {"embedded", "api"},
+			"format":         outputFormats,
+		}, commands, verseCounts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -completion: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Valid shells: bash, zsh, fish")
+			os.Exit(exitUsage)
+		}
+		fmt.Print(script)
+		os.Exit(0)
+	}
+
+	// open output destination
+	dest, err := openOutput(*outputPath, *buffered, *jsonOutput || *exportFormat == "json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening output: %v\n", err)
+		os.Exit(exitError)
+	}
+	dest.pager = !*noPager
+	closeOutput := func() {
+		if err := dest.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	// seed the random source, from -seed when given for reproducible picks
+	seed := time.Now().UnixNano()
+	if flagSet("seed") {
+		seed = *seedFlag
+	}
+	rng := rand.New(rand.NewSource(seed))
+	r := renderer{text: book.Info(), source: *translationSource, wrapper: wrap, width: width, stripHTML: *stripTags, bilingual: *bilingual, plainHeader: *plainHeader, autoSource: *autoSource, lang: *lang, summary: *chapterSummary, commentary: *commentary || *commentaryOnly, commentaryOnly: *commentaryOnly, wordMeanings: *wordMeanings, scheme: *scheme, cite: *cite, highlight: *highlight,
+		allTranslations: *allSources, compared: compared, blind: *blind, reveal: *reveal,
+		rng: rng}
+	r.split = *split
+	if *related {
+		r.related = book
+	}
+
+	// serve the HTTP API if requested
+	if *serve {
+		if !slices.Contains(serveProtocols, *protocol) {
+			fmt.Fprintf(os.Stderr, "Invalid protocol: %s\n", *protocol)
+			fmt.Fprintf(os.Stderr, "Valid protocols: %s\n", strings.Join(serveProtocols, ", "))
+			os.Exit(exitUsage)
+		}
+		// text responses are never styled and default to the fixed width
+		disableColor()
+		if !flagSet("width") {
+			r.width = displayWidth
+		}
+		sv := &server{book: book, r: r, rng: rng, loc: now.Location()}
+		var cert tls.Certificate
+		switch *protocol {
+		case ProtocolHTTP:
+			addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+			fmt.Fprintf(os.Stderr, "Serving on http://%s\n", addr)
+			err = http.ListenAndServe(addr, sv.handler())
+		case ProtocolGemini:
+			if !flagSet("port") {
+				*port = geminiPort
+			}
+			cert, err = geminiCertificate(*tlsCert, *tlsKey, *host)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading the TLS certificate: %v\n", err)
+				os.Exit(exitError)
+			}
+			addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+			fmt.Fprintf(os.Stderr, "Serving on gemini://%s\n", addr)
+			err = sv.serveGemini(addr, cert)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving: %v\n", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	// show the personal notes under the verses
+	notes, err := loadNotes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring notes: %v\n", err)
+	}
+	if !*noNotes {
+		r.notes = notes
+	}
+
+	// write, show or remove notes if requested
+	if ref := firstNonEmpty(*noteAdd, *noteShow, *noteRm); ref != "" || *noteList {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading notes: %v\n", err)
+			os.Exit(exitError)
+		}
+		if *noteList {
+			if len(notes) == 0 {
+				fmt.Fprintln(os.Stderr, "No notes yet; write one with: gitasay note add 2.47 \"...\"")
+			}
+			printNoteList(dest, book, notes, r)
+			closeOutput()
+			os.Exit(0)
+		}
+		sloka, err := parseVerse(book, ref)
+		if errors.Is(err, errNoVerse) {
+			notFound(*jsonOutput, msgf("Verse %s not found.", ref))
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid verse: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		switch {
+		case *noteAdd != "":
+			text := *noteText
+			if text == "" && !isTerminal(os.Stdin) {
+				data, _ := io.ReadAll(os.Stdin)
+				text = string(data)
+			}
+			if text = strings.TrimSpace(text); text == "" {
+				fmt.Fprintln(os.Stderr, "Invalid flags: -note-add needs the text of the note, with -note or on stdin")
+				os.Exit(exitUsage)
+			}
+			notes[sloka.ID] = append(notes[sloka.ID], note{Date: time.Now().Format(time.DateOnly), Text: text})
+		case len(notes[sloka.ID]) == 0:
+			notFound(*jsonOutput, msgf("No notes on verse %d.%d.", sloka.Chapter, sloka.Verse))
+		case *noteShow != "":
+			r.notes = notes
+			r.printNotes(dest, sloka)
+			closeOutput()
+			os.Exit(0)
+		default:
+			delete(notes, sloka.ID)
+		}
+		if err := saveNotes(notes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving notes: %v\n", err)
+			os.Exit(exitError)
+		}
+		os.Exit(0)
+	}
+
+	// list chapters if requested
+	if *listChapters {
+		printChapterList(dest, allSlokas.Chapters, *lang)
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// print a reading plan if requested
+	if *plan {
+		// today is the day -daily shows, in -daily-tz or given by -date
+		start := now
+		if *planStart != "" {
+			start, err = time.ParseInLocation(time.DateOnly, *planStart, now.Location())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid start date %q: use YYYY-MM-DD\n", *planStart)
+				os.Exit(exitUsage)
+			}
+		}
+		if *perDay < 1 {
+			fmt.Fprintf(os.Stderr, "Invalid verses per day: %d (must be at least 1)\n", *perDay)
+			os.Exit(exitUsage)
+		}
+
+		days := readingPlan(allSlokas.Slokas, start, *perDay)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(days)
+		} else {
+			err = writePlanCSV(dest, days)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing plan: %v\n", err)
+			os.Exit(exitError)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// start, follow or check the persisted reading plan if requested
+	if *planBegin || *planToday || *planStatus {
+		ordered := inOrder(allSlokas.Slokas)
+		today := now.Format(time.DateOnly)
+		if *planBegin {
+			start := today
+			if *planStart != "" {
+				if _, err := time.Parse(time.DateOnly, *planStart); err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid start date %q: use YYYY-MM-DD\n", *planStart)
+					os.Exit(exitUsage)
+				}
+				start = *planStart
+			}
+			if *planDays < 1 || *planDays > len(ordered) {
+				fmt.Fprintf(os.Stderr, "Invalid days: %d (must be 1 to %d)\n", *planDays, len(ordered))
+				os.Exit(exitUsage)
+			}
+			if err := saveProgress(readingProgress{Start: start, Days: *planDays}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving the reading plan: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Printf("Started a %d-day reading plan on %s, about %d verses a day.\n",
+				*planDays, start, (len(ordered)+*planDays-1) / *planDays)
+			fmt.Println("Read each day's verses with: gitasay plan today")
+			os.Exit(0)
+		}
+
+		progress, err := loadProgress()
+		if errors.Is(err, errNoPlan) {
+			notFound(*jsonOutput, msgf("No reading plan yet; start one with: gitasay plan start -days 90"))
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the reading plan: %v\n", err)
+			os.Exit(exitError)
+		}
+		st, err := progress.status(today, ordered)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the reading plan: %v\n", err)
+			os.Exit(exitError)
+		}
+		if *planStatus {
+			if *jsonOutput {
+				enc := json.NewEncoder(dest)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(st)
+			} else {
+				_, err = fmt.Fprintf(dest, "Day %d of %d, started %s\n%d of %d verses read (%.0f%%)\n%d days behind\nStreak: %d days\n",
+					min(max(st.Day, 0), st.Days), st.Days, st.Start, st.VersesRead, st.Verses, st.Percent, st.Behind, st.Streak)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing status: %v\n", err)
+				os.Exit(exitError)
+			}
+			closeOutput()
+			os.Exit(0)
+		}
+
+		day := st.Day - 1
+		switch {
+		case day < 0:
+			notFound(*jsonOutput, msgf("The reading plan starts on %s.", progress.Start))
+		case day >= progress.Days:
+			notFound(*jsonOutput, msgf("The reading plan ended after %d days; see: gitasay plan status", progress.Days))
+		}
+		verses := planVerses(ordered, progress.Days, day)
+		first, last := verses[0], verses[len(verses)-1]
+		fmt.Fprintf(dest, "\n%sDay %d of %d: %d.%d to %d.%d%s\n", Bold, st.Day, progress.Days,
+			first.Chapter, first.Verse, last.Chapter, last.Verse, Reset)
+		render(dest, book, verses, Options{renderer: r, ChapterInfo: *includeChapter})
+		if !*noTrack {
+			trackViews(verses, now)
+		}
+		progress.markRead(day, today)
+		if err := saveProgress(progress); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving the reading plan: %v\n", err)
+			os.Exit(exitError)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// write a feed of the daily verses if requested
+	if *feed {
+		days := 30
+		if flagSet("days") {
+			days = *planDays
+		}
+		switch {
+		case !slices.Contains(feedFormats, *feedFormat):
+			fmt.Fprintf(os.Stderr, "Invalid feed format: %s\n", *feedFormat)
+			fmt.Fprintf(os.Stderr, "Valid feed formats: %s\n", strings.Join(feedFormats, ", "))
+			os.Exit(exitUsage)
+		case days < 1:
+			fmt.Fprintf(os.Stderr, "Invalid days: %d (must be at least 1)\n", days)
+			os.Exit(exitUsage)
+		}
+		pool := allSlokas.Slokas
+		if *chapterFlag != 0 {
+			if pool = book.Verses(*chapterFlag); len(pool) == 0 {
+				notFound(*jsonOutput, msgf("No verses found in chapter %d.", *chapterFlag))
+			}
+		}
+		if err := r.writeFeed(dest, *feedFormat, dailyEntries(pool, now, days), *feedURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing feed: %v\n", err)
+			os.Exit(exitError)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// print the usage statistics if requested
+	if *usageFlag {
+		log, err := loadUsage()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading usage statistics: %v\n", err)
+			os.Exit(exitError)
+		}
+		stats := usageStats(log, book, time.Now().In(now.Location()).Format(time.DateOnly), *lang)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printUsageStats(dest, stats)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// print dataset statistics if requested
+	if *dataStats {
+		stats := datasetStats(book)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printDatasetStats(dest, stats)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// print translation length statistics if requested
+	if *lengthStats {
+		stats := translationStats(allSlokas.Slokas, r)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printLengthStats(dest, stats)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// list the glossary's terms if requested
+	if *listTerms {
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(gita.Terms())
+		} else {
+			_, err = fmt.Fprintln(dest, strings.Join(gita.Terms(), "\n"))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing terms: %v\n", err)
+			os.Exit(exitError)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// define a Sanskrit term if requested, optionally showing -n of its verses
+	if *define != "" {
+		term, ok := gita.Define(*define)
+		if !ok {
+			msg := msgf("No glossary entry for %q (see: gitasay define).", *define)
+			if similar := suggestTerms(*define); len(similar) > 0 {
+				msg = msgf("No glossary entry for %q; did you mean %s?", *define, strings.Join(similar, ", "))
+			}
+			notFound(*jsonOutput, msg)
+		}
+		occurrences := gita.Occurrences(book, term)
+		entry := termJSON(term, occurrences)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			r.printTerm(dest, entry)
+			if flagSet("n") {
+				render(dest, book, occurrences[:min(max(*count, 0), len(occurrences))], Options{renderer: r})
+			}
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// list the verses containing a Sanskrit word, or each chapter's most
+	// frequent words, if requested
+	if *concordanceWord != "" || *wordFreq {
+		display := *scheme
+		if display == "" {
+			display = SchemeIAST
+		}
+		slokas := inOrder(allSlokas.Slokas)
+		if *chapterFlag != 0 {
+			if slokas = inOrder(book.Verses(*chapterFlag)); len(slokas) == 0 {
+				notFound(*jsonOutput, msgf("No chapter %d.", *chapterFlag))
+			}
+		}
+		idx := buildIndex(slokas)
+		var result any
+		if *wordFreq {
+			n := topWords
+			if *limit > 0 {
+				n = *limit
+			}
+			chapters := wordFrequencies(idx, book, *chapterFlag, n, display, *lang)
+			result = chapters
+			if !*jsonOutput {
+				dest.pageIfLong()
+				printWordFrequencies(dest, chapters)
+			}
+		} else {
+			key := normalizeWord(*concordanceWord, *scheme)
+			if key == "" {
+				fmt.Fprintf(os.Stderr, "Invalid word %q: no Sanskrit letters\n", *concordanceWord)
+				os.Exit(exitUsage)
+			}
+			c := idx.lookup(key, display)
+			if len(c.slokas) == 0 {
+				notFound(*jsonOutput, msgf("No verses contain %q.", key))
+			}
+			if *limit > 0 && len(c.slokas) > *limit {
+				c.slokas = c.slokas[:*limit]
+			}
+			result = c.ConcordanceJSON
+			if !*jsonOutput {
+				dest.pageIfLong()
+				printConcordance(dest, c, r, display)
+			}
+		}
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// list the topics if requested
+	if *listTopics {
+		topics := topicCounts(book)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(topics); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printTopics(dest, topics)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// list the occasions if requested
+	if *listOccasions {
+		list := occasionList(book, now)
+		if *jsonOutput {
+			enc := json.NewEncoder(dest)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(list); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			printOccasions(dest, list)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// manage the favorites if requested
+	if *favAdd != "" || *favRm != "" || *favList {
+		ids, err := loadFavorites()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading favorites: %v\n", err)
+			os.Exit(exitError)
+		}
+		switch {
+		case *favList:
+			if *jsonOutput {
+				enc := json.NewEncoder(dest)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(append([]string{}, ids...)); err != nil {
+					fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+					os.Exit(exitError)
+				}
+			} else if len(ids) == 0 {
+				fmt.Fprintln(os.Stderr, "No favorites yet; add one with: gitasay fav add 2:47")
+			} else {
+				printFavorites(dest, favoriteSlokas(book, ids), r)
+			}
+		default:
+			ref := *favAdd
+			if ref == "" {
+				ref = *favRm
+			}
+			sloka, err := parseVerse(book, ref)
+			if errors.Is(err, errNoVerse) {
+				notFound(*jsonOutput, msgf("Verse %s not found.", ref))
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid verse: %v\n", err)
+				os.Exit(exitUsage)
+			}
+			i := slices.Index(ids, sloka.ID)
+			switch {
+			case *favAdd != "" && i < 0:
+				ids = append(ids, sloka.ID)
+			case *favRm != "" && i >= 0:
+				ids = slices.Delete(ids, i, i+1)
+			case *favRm != "":
+				notFound(*jsonOutput, msgf("Verse %d.%d is not a favorite.", sloka.Chapter, sloka.Verse))
+			}
+			if err := saveFavorites(ids); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving favorites: %v\n", err)
+				os.Exit(exitError)
+			}
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// review the due favorites if requested
+	if *reviewFlag {
+		ids, err := loadFavorites()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading favorites: %v\n", err)
+			os.Exit(exitError)
+		}
+		cards, err := loadReviews()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reviews: %v\n", err)
+			os.Exit(exitError)
+		}
+		if len(ids) == 0 {
+			notFound(false, msgf("No favorites to review yet; add one with: gitasay fav add 2:47"))
+		}
+		due := dueSlokas(book, ids, cards, now)
+		if flagSet("n") && *count < len(due) {
+			due = due[:max(*count, 0)]
+		}
+		if len(due) == 0 {
+			fmt.Printf("No verses due; the next review is on %s.\n", nextDue(ids, cards))
+			os.Exit(0)
+		}
+		reviewed, err := review(os.Stdout, due, cards, r, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reviewing: %v\n", err)
+			os.Exit(exitError)
+		}
+		fmt.Printf("\n%s\n", r.header(fmt.Sprintf("Reviewed %d of %d due verses.", reviewed, len(due))))
+		os.Exit(0)
+	}
+
+	// list verses matching a search term if requested
+	var matches []searchMatch
+	if *search != "" {
+		fields, err := parseSearchFields(*searchIn)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -search-in: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Valid fields: translation, translations, sanskrit, transliteration or a source key")
+			os.Exit(exitUsage)
+		}
+		pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(*search))
+		matches = searchSlokas(allSlokas.Slokas, r, pattern, fields)
+		if *limit > 0 && len(matches) > *limit {
+			matches = matches[:*limit]
+		}
+		if len(matches) == 0 {
+			notFound(*jsonOutput, msgf("No verses found matching %q.", *search))
+		}
+		// a single match is shown in full like a selected verse
+		if !*searchRandom && len(matches) > 1 {
+			dest.pageIfLong()
+			switch verseFormatName {
+			case FormatJSON, FormatYAML, FormatMarkdown:
+				// scripts get the matching verses in the chosen format
+				slokas := make([]Sloka, len(matches))
+				for i, m := range matches {
+					slokas[i] = m.sloka
+				}
+				var vf verseFormat = markdownFormat{r: r, book: book, chapterInfo: *includeChapter}
+				if verseFormatName != FormatMarkdown {
+					vf = jsonFormat{r: r, book: book, allTranslations: *allTranslations, chapterInfo: *includeChapter, yaml: verseFormatName == FormatYAML}
+				}
+				if err := vf.writeVerses(dest, slokas); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+					os.Exit(exitError)
+				}
+			default:
+				printSearchResults(dest, matches, r, pattern)
+			}
+			closeOutput()
+			os.Exit(0)
+		}
+	} else if *searchRandom {
+		fmt.Fprintln(os.Stderr, "Invalid flags: -search-random requires -search")
+		os.Exit(exitUsage)
+	}
+
+	// read whole chapters through the pager if requested
+	if *readRange != "" {
+		first, last, err := parseRange(*readRange)
+		if err == nil && (first < 1 || last > len(allSlokas.Chapters)) {
+			err = fmt.Errorf("valid chapters are 1-%d", len(allSlokas.Chapters))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid chapter range %q: %v\n", *readRange, err)
+			os.Exit(exitUsage)
+		}
+
+		// held for the pager on a terminal, otherwise streamed verse by verse
+		dest.pageIfLong()
+		fmt.Fprint(dest, unescape(*prefix))
+		fmt.Fprintln(dest)
+		for number := first; number <= last; number++ {
+			if chapter, ok := book.Chapter(number); ok {
+				r.chapterInfo(dest, chapter)
+			}
+			for _, sloka := range book.Verses(number) {
+				r.sloka(dest, sloka)
+			}
+		}
+		fmt.Fprint(dest, unescape(*suffix))
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// parse the requested verse or verse range
+	var firstVerse, lastVerse int
+	verseGiven := *verseFlag != ""
+	if verseGiven && *verseFlag != "all" {
+		firstVerse, lastVerse, err = parseRange(*verseFlag)
+		if err == nil && firstVerse < 1 {
+			err = errors.New("verses are numbered from 1")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid verse %q: %v\n", *verseFlag, err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	// validate the requested chapter and verse against the chapter metadata
+	if verseGiven && *chapterFlag == 0 {
+		fmt.Fprintln(os.Stderr, "Invalid flags: -v needs -c to pick the chapter, e.g. -c 2 -v 47")
+		os.Exit(exitUsage)
+	}
+	if *chapterFlag != 0 {
+		chapter, ok := book.Chapter(*chapterFlag)
+		if !ok {
+			notFound(*jsonOutput, msgf("Chapter %d not found; valid chapters are 1-%d.", *chapterFlag, len(allSlokas.Chapters)))
+		}
+		if *verseFlag == "all" {
+			firstVerse, lastVerse = 1, chapter.VersesCount
+		}
+		if verseGiven && lastVerse > chapter.VersesCount {
+			notFound(*jsonOutput, msgf("Chapter %d has only %d verses.", *chapterFlag, chapter.VersesCount))
+		}
+	}
+
+	// describe the chosen chapter without a verse if requested
+	if *chapterOnly {
+		chapter, ok := book.Chapter(*chapterFlag)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -chapter-only needs -c to pick the chapter, e.g. -c 12")
+			os.Exit(exitUsage)
+		}
+		fmt.Fprintln(dest)
+		r.chapterInfo(dest, chapter)
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// export the book or the chosen chapter if requested
+	if *exportFormat != "" {
+		slokas := allSlokas.Slokas
+		if *chapterFlag != 0 {
+			slokas = book.Verses(*chapterFlag)
+		}
+		if *exportFormat != "epub" {
+			dest.pageIfLong()
+		}
+		if *exportFormat == "fortune" {
+			entries, err := r.exportFortune(dest, inOrder(slokas))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing export: %v\n", err)
+				os.Exit(exitError)
+			}
+			closeOutput()
+			if *strfile {
+				if err := writeStrfile(*outputPath+".dat", entries); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing strfile index: %v\n", err)
+					os.Exit(exitError)
+				}
+			}
+			os.Exit(0)
+		}
+		if err := r.export(dest, *exportFormat, book, inOrder(slokas), *allTranslations); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing export: %v\n", err)
+			os.Exit(exitError)
+		}
+		closeOutput()
+		os.Exit(0)
+	}
+
+	// draw random verses from the -chapters list only if given
+	var chapters []int
+	if *chaptersFlag != "" {
+		if *chapterFlag != 0 || *rotate {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -chapters cannot be combined with -c or -rotate")
+			os.Exit(exitUsage)
+		}
+		if chapters, err = parseChapters(*chaptersFlag, len(allSlokas.Chapters)); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid chapters %q: %v\n", *chaptersFlag, err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	var selectedSloka Sloka
+	var pool []Sloka // slokas a random pick was drawn from
+
+	// if specific verse requested
+	if len(matches) > 0 {
+		// the only search result, or a random one of them
+		selectedSloka = matches[rng.Intn(len(matches))].sloka
+	} else if *idFlag != "" {
+		sloka, ok := book.ByID(*idFlag)
+		if !ok {
+			notFound(*jsonOutput, msgf("Verse id %s not found (ids look like BG2.47).", *idFlag))
+		}
+		selectedSloka = sloka
+	} else if *topic != "" {
+		// pick a sloka tagged with the topic, from the -c chapter if given
+		tagged, ok := book.Topic(*topic)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Invalid topic: %s\n", *topic)
+			fmt.Fprintf(os.Stderr, "Valid topics: %s\n", strings.Join(gita.Topics(), ", "))
+			os.Exit(exitUsage)
+		}
+		for _, s := range tagged {
+			if (*chapterFlag == 0 || s.Chapter == *chapterFlag) && (chapters == nil || slices.Contains(chapters, s.Chapter)) {
+				pool = append(pool, s)
+			}
+		}
+		if len(pool) == 0 && chapters != nil {
+			notFound(*jsonOutput, msgf("No verses on %s in chapters %s.", *topic, *chaptersFlag))
+		} else if len(pool) == 0 {
+			notFound(*jsonOutput, msgf("No verses on %s in chapter %d.", *topic, *chapterFlag))
+		}
+		selectedSloka = selectSloka(pool, *selectFlag, r, rng)
+	} else if *occasionFlag != "" {
+		// pick a sloka for the occasion, or for the first of today's with
+		// verses in the -c chapter if given
+		candidates := occasionsOn(now)
+		if *occasionFlag != occasionAuto {
+			o, ok := findOccasion(*occasionFlag)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Invalid occasion: %s\n", *occasionFlag)
+				fmt.Fprintf(os.Stderr, "Valid occasions: %s, %s\n", occasionAuto, strings.Join(occasionNames(), ", "))
+				os.Exit(exitUsage)
+			}
+			candidates = []occasion{o}
+		}
+		for _, o := range candidates {
+			for _, s := range o.slokas(book) {
+				if (*chapterFlag == 0 || s.Chapter == *chapterFlag) && (chapters == nil || slices.Contains(chapters, s.Chapter)) {
+					pool = append(pool, s)
+				}
+			}
+			if len(pool) > 0 {
+				break
+			}
+		}
+		switch {
+		case len(pool) > 0:
+		case chapters != nil:
+			notFound(*jsonOutput, msgf("No verses for %s in chapters %s.", *occasionFlag, *chaptersFlag))
+		case *chapterFlag != 0:
+			notFound(*jsonOutput, msgf("No verses for %s in chapter %d.", *occasionFlag, *chapterFlag))
+		default:
+			notFound(*jsonOutput, msgf("No verses for %s in %s.", *occasionFlag, book.Info().Title))
+		}
+		if *daily {
+			selectedSloka = pool[periodIndex(dayKey(now), len(pool))]
+		} else {
+			selectedSloka = selectSloka(pool, *selectFlag, r, rng)
+		}
+	} else if *favRandom {
+		// pick a sloka among the favorites
+		ids, err := loadFavorites()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading favorites: %v\n", err)
+			os.Exit(exitError)
+		}
+		if pool = favoriteSlokas(book, ids); len(pool) == 0 {
+			notFound(*jsonOutput, msgf("No favorites yet; add one with: gitasay fav add 2:47"))
+		}
+		selectedSloka = selectSloka(pool, *selectFlag, r, rng)
+	} else if *chapterFlag != 0 && verseGiven {
+		sloka, ok := book.Get(*chapterFlag, firstVerse)
+		if !ok {
+			notFound(*jsonOutput, msgf("Chapter %d, Verse %d not found.", *chapterFlag, firstVerse))
+		}
+		selectedSloka = sloka
+	} else if *chapterFlag != 0 {
+		// pick a sloka within the chapter
+		inChapter := book.Verses(*chapterFlag)
+		if len(inChapter) == 0 {
+			notFound(*jsonOutput, msgf("No verses found in chapter %d.", *chapterFlag))
+		}
+		switch {
+		case *daily:
+			// the chapter's sloka of the day
+			selectedSloka = inChapter[periodIndex(dayKey(now), len(inChapter))]
+		case *weekly:
+			selectedSloka = inChapter[periodIndex(weekKey(now), len(inChapter))]
+		default:
+			pool = inChapter
+			selectedSloka = selectSloka(pool, *selectFlag, r, rng)
+		}
+	} else if chapters != nil {
+		// pick a sloka within the listed chapters
+		var inChapters []Sloka
+		for _, number := range chapters {
+			inChapters = append(inChapters, inOrder(book.Verses(number))...)
+		}
+		switch {
+		case *daily:
+			selectedSloka = inChapters[periodIndex(dayKey(now), len(inChapters))]
+		case *weekly:
+			selectedSloka = inChapters[periodIndex(weekKey(now), len(inChapters))]
+		default:
+			pool = inChapters
+			selectedSloka = selectSloka(pool, *selectFlag, r, rng)
+		}
+	} else if *rotate {
+		// advance the persisted shuffled rotation
+		index, err := nextRotation(len(allSlokas.Slokas), rng)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating rotation state: %v\n", err)
+			os.Exit(exitError)
+		}
+		selectedSloka = allSlokas.Slokas[index]
+	} else if *daily {
+		// same sloka for the whole day, changing at midnight
+		selectedSloka = allSlokas.Slokas[periodIndex(dayKey(now), len(allSlokas.Slokas))]
+	} else if *weekly {
+		// same sloka for the whole ISO week, changing on Monday
+		selectedSloka = allSlokas.Slokas[periodIndex(weekKey(now), len(allSlokas.Slokas))]
+	} else {
+		// pick a sloka from the whole book
+		pool = allSlokas.Slokas
+		selectedSloka = selectSloka(pool, *selectFlag, r, rng)
+	}
+
+	// let the user choose the verse from the pool if requested
+	if *pickFlag {
+		if pool == nil {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -pick chooses among verses, from the book, a chapter, a topic or the favorites")
+			os.Exit(exitUsage)
+		}
+		ordered := inOrder(pool)
+		i, err := pick(pickLines(ordered, r))
+		if errors.Is(err, errNoPick) {
+			os.Exit(exitNotFound)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error picking a verse: %v\n", err)
+			os.Exit(exitError)
+		}
+		selectedSloka = ordered[i]
+	}
+
+	// quiz on verses of the pool if requested
+	if *quizFlag {
+		switch {
+		case !slices.Contains(quizModes, *quizMode):
+			fmt.Fprintf(os.Stderr, "Invalid quiz mode: %s\n", *quizMode)
+			fmt.Fprintf(os.Stderr, "Valid quiz modes: %s\n", strings.Join(quizModes, ", "))
+			os.Exit(exitUsage)
+		case pool == nil:
+			fmt.Fprintln(os.Stderr, "Invalid flags: -quiz needs random verses, from the book, a chapter, a topic or the favorites")
+			os.Exit(exitUsage)
+		}
+		questions := 10
+		if flagSet("n") {
+			questions = *count
+		}
+		if questions < 1 {
+			fmt.Fprintf(os.Stderr, "Invalid count: %d (must be at least 1)\n", questions)
+			os.Exit(exitUsage)
+		}
+		if err := quiz(os.Stdout, book, pool, questions, *quizMode, r, rng); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading answers: %v\n", err)
+			os.Exit(exitError)
+		}
+		os.Exit(0)
+	}
+
+	// post verses as notifications until stopped if requested
+	if *notify {
+		var hour, minute int
+		switch {
+		case pool == nil:
+			fmt.Fprintln(os.Stderr, "Invalid flags: -notify needs random verses, from the book, a chapter, a topic or the favorites")
+			os.Exit(exitUsage)
+		case (*notifyEvery > 0) == (*notifyAt != ""):
+			fmt.Fprintln(os.Stderr, "Invalid flags: -notify needs one of -every (e.g. 4h) or -at (e.g. 07:00)")
+			os.Exit(exitUsage)
+		case *notifyAt != "":
+			var err error
+			if hour, minute, err = parseClock(*notifyAt); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+				os.Exit(exitUsage)
+			}
+		case *notifyEvery < time.Minute:
+			fmt.Fprintf(os.Stderr, "Invalid flags: -every %s is shorter than a minute\n", *notifyEvery)
+			os.Exit(exitUsage)
+		}
+		if err := notifyLoop(pool, r, rng, *notifyEvery, *notifyAt != "", hour, minute); err != nil {
+			fmt.Fprintf(os.Stderr, "Error posting notifications: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Install notify-send (libnotify) to use -notify")
+			os.Exit(exitError)
+		}
+	}
+
+	if *contextCount < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid context: %d (must not be negative)\n", *contextCount)
+		os.Exit(exitUsage)
+	}
+
+	// draw distinct random slokas if several were requested
+	picks := []Sloka{selectedSloka}
+	if *count != 1 {
+		switch {
+		case *count < 1:
+			fmt.Fprintf(os.Stderr, "Invalid count: %d (must be at least 1)\n", *count)
+			os.Exit(exitUsage)
+		case pool == nil || *selectFlag != SelectRandom:
+			fmt.Fprintln(os.Stderr, "Invalid flags: -n only applies to random verses")
+			os.Exit(exitUsage)
+		case *count > len(pool):
+			fmt.Fprintf(os.Stderr, "Invalid count: %d (only %d verses available)\n", *count, len(pool))
+			os.Exit(exitUsage)
+		}
+		picks = picks[:0]
+		for _, i := range rng.Perm(len(pool))[:*count] {
+			picks = append(picks, pool[i])
+		}
+	}
+
+	// redraw among the verses not shown before
+	if *noRepeat {
+		if pool == nil || *selectFlag != SelectRandom {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -no-repeat only applies to random verses")
+			os.Exit(exitUsage)
+		}
+		var err error
+		if picks, err = drawUnseen(pool, len(picks), rng); err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating history state: %v\n", err)
+			os.Exit(exitError)
+		}
+	}
+
+	// show every verse of a requested range, in order
+	if lastVerse > firstVerse {
+		picks = picks[:0]
+		for verse := firstVerse; verse <= lastVerse; verse++ {
+			if sloka, ok := book.Get(*chapterFlag, verse); ok {
+				picks = append(picks, sloka)
+			}
+		}
+	}
+
+	// show the verses cited on stdin instead if requested
+	if *stdinFlag {
+		if flagSet("c") || flagSet("v") || flagSet("id") {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -stdin takes the verses from stdin, not -c, -v or -id")
+			os.Exit(exitUsage)
+		}
+		cited, err := readCitations(os.Stdin, book)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid citations on stdin: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		if len(cited) == 0 {
+			notFound(*jsonOutput, msgf("No verses cited on stdin."))
+		}
+		picks, selectedSloka = cited, cited[0]
+	}
+
+	// add the verse to compare with if requested
+	if *compareRef != "" {
+		if len(picks) != 1 {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -compare compares a single verse with another")
+			os.Exit(exitUsage)
+		}
+		other, err := parseCitation(book, *compareRef)
+		switch {
+		case errors.Is(err, errNoVerse):
+			notFound(*jsonOutput, msgf("Verse %s not found.", *compareRef))
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Invalid flags: -compare: %v\n", err)
+			os.Exit(exitUsage)
+		case len(other) != 1:
+			fmt.Fprintf(os.Stderr, "Invalid flags: -compare takes one verse, not %d\n", len(other))
+			os.Exit(exitUsage)
+		}
+		picks = append(picks, other[0])
+	}
+
+	// fetch the chosen verses from the API if requested, through the cache
+	// when there is one; when a request fails, the rest are shown from the
+	// embedded data
+	if *dataSource == "api" {
+		api := gita.NewAPI(*apiURL, *apiTimeout)
+		if cache, err := newHTTPCache(*cacheTTL); err == nil {
+			api.Client.Transport = cache
+		}
+		for i, sloka := range picks {
+			fetched, err := api.Sloka(sloka.Chapter, sloka.Verse)
+			if err != nil && *strict {
+				fail(*jsonOutput, exitData, fmt.Sprintf("Error fetching verse %d.%d: %v", sloka.Chapter, sloka.Verse, err))
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; using embedded data\n", err)
+				break
+			}
+			picks[i] = fetched
+		}
+	}
+
+	// render verses as text, in a speech bubble with -cow
+	opts := Options{renderer: r, ChapterInfo: *includeChapter, Cow: *cow, Figure: *figure, Context: *contextCount, Compare: *compareRef != ""}
+	if *box {
+		opts.Box = *boxStyle
+	}
+	if *center {
+		opts.Center = terminalWidth()
+	}
+	renderText := func(slokas ...Sloka) string {
+		var out strings.Builder
+		render(&out, book, slokas, opts)
+		return out.String()
+	}
+
+	// browse from the selected verse if requested
+	if *interactive {
+		if !isTerminal(os.Stdin) {
+			fmt.Fprintln(os.Stderr, "Invalid flags: -interactive needs a terminal on stdin")
+			os.Exit(exitUsage)
+		}
+		if err := browse(book, selectedSloka, rng, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading keys: %v\n", err)
+			os.Exit(exitError)
+		}
+		os.Exit(0)
+	}
+
+	translationText, _ := r.resolve(selectedSloka).translation(selectedSloka)
+
+	// print a single commit-message line if requested
+	if *commitMsg {
+		fmt.Println(commitLine(selectedSloka, r.text.Abbrev, translationText))
+		os.Exit(0)
+	}
+
+	// print a single status-bar line if requested, for prompts without the
+	// newline that would break them
+	if *status || *oneline {
+		line := statusLine(selectedSloka, r.text.Abbrev, translationText, *statusWidth)
+		if !*oneline {
+			line += "\n"
+		}
+		fmt.Print(line)
+		os.Exit(0)
+	}
+
+	// post the verse to a chat if requested
+	if *botFlag {
+		webhook := firstNonEmpty(*botURL, os.Getenv("GITASAY_BOT_URL"))
+		platform := firstNonEmpty(*botPlatform, detectPlatform(webhook))
+		switch {
+		case webhook == "" && !*dryRun:
+			fmt.Fprintln(os.Stderr, "Invalid flags: -bot needs the webhook URL, from -bot-url or $GITASAY_BOT_URL")
+			os.Exit(exitUsage)
+		case !slices.Contains(botPlatforms, platform):
+			fmt.Fprintf(os.Stderr, "Invalid flags: set -bot-platform to one of %s\n", strings.Join(botPlatforms, ", "))
+			os.Exit(exitUsage)
+		}
+		body, err := r.botPayload(platform, selectedSloka, *botChat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		if *dryRun {
+			fmt.Fprintf(dest, "POST %s (%s)\n%s\n", redactURL(webhook), platform, body)
+			closeOutput()
+			os.Exit(0)
+		}
+		if err := postWebhook(webhook, body, max(*botRetries, 0)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error posting to %s: %v\n", platform, err)
+			os.Exit(exitError)
+		}
+		os.Exit(0)
+	}
+
+	// count the shown verses in the usage statistics, once they are shown
+	countViews := func() {
+		if !*noTrack {
+			trackViews(picks, now)
+		}
+	}
+
+	// write the verse as a quote card if requested
+	if *imagePath != "" {
+		for _, color := range []string{*imageBackground, *imageForeground} {
+			if err := parseHexColor(color); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+				os.Exit(exitUsage)
+			}
+		}
+		if err := r.writeCardFile(*imagePath, selectedSloka, *imageBackground, *imageForeground); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing image: %v\n", err)
+			if errors.Is(err, errNoConverter) {
+				fmt.Fprintln(os.Stderr, "Install rsvg-convert, ImageMagick or Inkscape to write PNG cards, or write an .svg")
+			}
+			os.Exit(exitError)
+		}
+		countViews()
+		os.Exit(0)
+	}
+
+	// write the verse onto a wallpaper, and set it, if requested
+	if *wallpaperPath != "" {
+		width, height, err := parseResolution(*resolution)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		for _, color := range []string{*imageBackground, *imageForeground} {
+			if err := parseHexColor(color); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid flags: %v\n", err)
+				os.Exit(exitUsage)
+			}
+		}
+		err = r.writeWallpaperFile(*wallpaperPath, selectedSloka, width, height, *wallpaperImage, *imageBackground, *imageForeground)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing wallpaper: %v\n", err)
+			if errors.Is(err, errNoConverter) {
+				fmt.Fprintln(os.Stderr, "Install rsvg-convert, ImageMagick or Inkscape to write PNG wallpapers, or write an .svg")
+			}
+			os.Exit(exitError)
+		}
+		if *setWallpaperFlag {
+			if err := setWallpaper(*wallpaperPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Error setting wallpaper: %v\n", err)
+				if errors.Is(err, errNoSetter) {
+					fmt.Fprintln(os.Stderr, "Install feh, or set the wallpaper to the file with your desktop's settings")
+				}
+				os.Exit(exitError)
+			}
+		}
+		countViews()
+		os.Exit(0)
+	}
+
+	// show list of available translators if requested
+	if *listTranslators {
+		fmt.Println()
+		fmt.Println("Available translation sources:")
+		for _, source := range validSources {
+			fmt.Printf(" - %s\n", source)
+		}
+		os.Exit(0)
+	}
+
+	// render into a buffer so the output can be shaped before writing
+	rendered := renderText(picks...)
+
+	// copy to the clipboard if requested, still printing the verse
+	if *copyFlag {
+		text := strings.TrimSpace(rendered) + "\n"
+		if *copyFormat == CopyTranslation {
+			text = copiedTranslations(picks, r)
+		}
+		if err := copyToClipboard(text); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not copy to the clipboard: %v\n", err)
+			if errors.Is(err, errNoClipboard) {
+				fmt.Fprintln(os.Stderr, "Install pbcopy, wl-copy, xclip, xsel or clip to use -copy")
+			}
+		}
+	}
+
+	// download the recitation instead of printing the verse if requested
+	if *audioDownload {
+		file, err := fetchAudio(audioCache, *audioTemplate, selectedSloka)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error downloading audio: %v\n", err)
+			os.Exit(exitError)
+		}
+		fmt.Println(file)
+		os.Exit(0)
+	}
+
+	// write the verses in the chosen format
+	countViews()
+	tty := ttyFormat{opts: opts, book: book, prefix: *prefix, suffix: *suffix, styled: styled}
+	// animation is for people watching a terminal; elsewhere the verse is
+	// written at once
+	animated := *animateFlag && verseFormatName == FormatText && dest.toStdout() && isTerminal(os.Stdout)
+	tty.opts.animate = animated
+	var vf verseFormat = tty
+	switch verseFormatName {
+	case FormatPlain:
+		vf = plainFormat{tty}
+	case FormatJSON, FormatYAML:
+		vf = jsonFormat{r: r, book: book, allTranslations: *allTranslations, chapterInfo: *includeChapter, yaml: verseFormatName == FormatYAML}
+	case FormatMarkdown:
+		vf = markdownFormat{r: r, book: book, chapterInfo: *includeChapter}
+	}
+	if animated {
+		var out strings.Builder
+		err = vf.writeVerses(&out, picks)
+		if err == nil {
+			err = animate(dest, out.String(), *animateDelay, styled)
+		}
+	} else {
+		dest.pageIfLong()
+		err = vf.writeVerses(dest, picks)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(exitError)
+	}
+	closeOutput()
+
+	// read the translation of each verse aloud if requested
+	if *speakFlag {
+		endpoint := firstNonEmpty(*speakURL, os.Getenv("GITASAY_SPEAK_URL"))
+		for _, sloka := range picks {
+			text := r.spokenText(sloka)
+			var err error
+			if endpoint != "" {
+				err = speakRemote(endpoint, os.Getenv("GITASAY_SPEAK_TOKEN"), text, *voice, *speakRate)
+			} else {
+				err = speak(text, *voice, *speakRate)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error speaking the verse: %v\n", err)
+				if errors.Is(err, errNoSpeaker) {
+					fmt.Fprintln(os.Stderr, "Install espeak-ng or espeak to use -speak, or set -speak-url")
+				}
+				os.Exit(exitError)
+			}
+		}
+	}
+
+	// play the recitation of each verse after showing them if requested
+	if *audio {
+		for _, sloka := range picks {
+			file, err := fetchAudio(audioCache, *audioTemplate, sloka)
+			if err == nil {
+				err = playAudio(file)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+				if errors.Is(err, errNoPlayer) {
+					fmt.Fprintf(os.Stderr, "Install mpv, ffplay or mpg123 to use -audio; the recitation is in %s\n", file)
+				}
+				os.Exit(exitError)
+			}
+		}
+	}
+}
+
+// renderer holds the display settings shared by every printed verse
+type renderer struct {
+	source          string
+	wrapper         func(text string, width int) string
+	width           int
+	stripHTML       bool
+	bilingual       bool
+	plainHeader     bool
+	commentary      bool   // print the source's commentary after the translation
+	commentaryOnly  bool   // print the header and the commentary only
+	wordMeanings    bool   // print the word-by-word glosses under the verse
+	scheme          string // romanization of the transliteration, empty for the dataset's
+	summary         bool   // include the chapter summary in chapterInfo
+	allTranslations bool
+	compared        []string          // sources shown by allTranslations, nil for every source
+	blind           bool              // hide authors in -all-translations
+	reveal          bool              // print the blind key
+	rng             *rand.Rand        // orders the blind sources
+	lang            string            // chapter text language, "en" or "hi"
+	autoSource      string            // heuristic for picking the source per verse, empty to disable
+	cite            string            // citation style printed after the translation
+	highlight       bool              // style the Sanskrit, transliteration and translation blocks
+	fallbackFrom    string            // source that was empty for the verse being rendered, set by resolve
+	text            gita.Text         // scripture being shown, for titles and citations
+	notes           map[string][]note // personal notes shown under each verse, by sloka id
+	related         gita.Scripture    // book the -related cross-references are listed from, nil for none
+	split           bool              // print the pada-patha under each Sanskrit line
+	animate         bool              // mark the translation for -animate to fade in
+}
+
+// resolve returns a copy of r whose source is the one to display for s,
+// applying the -auto-source heuristic when set. When that source has no
+// text for s, the first source with text in -list-sources order is used
+// instead and fallbackFrom records the source that was empty.
+func (r renderer) resolve(s Sloka) renderer {
+	if r.autoSource != "" {
+		r.source = r.bestSource(s)
+	}
+	if _, _, ok := resolveTranslation(s, r.source); !ok {
+		for _, t := range translators {
+			if _, _, ok := resolveTranslation(s, t.Key); ok {
+				r.fallbackFrom, r.source = r.source, t.Key
+				break
+			}
+		}
+	}
+	return r
+}
+
+// bestSource picks a translation source for s: "longest" chooses the source
+// with the longest non-empty text, "en" and "hi" the first non-empty source
+// in that language in -list-sources order. It keeps the configured source
+// when no candidate has text.
+func (r renderer) bestSource(s Sloka) string {
+	best, bestLen := r.source, 0
+	for _, t := range translators {
+		if r.autoSource != "longest" && t.Lang != r.autoSource {
+			continue
+		}
+		c := r
+		c.source = t.Key
+		text, _ := c.translation(s)
+		length := utf8.RuneCountInString(cleanTranslation(text))
+		if length == 0 {
+			continue
+		}
+		if r.autoSource != "longest" {
+			return t.Key
+		}
+		if length > bestLen {
+			best, bestLen = t.Key, length
+		}
+	}
+	return best
+}
+
+// wrap wraps text to the line width with the selected algorithm
+func (r renderer) wrap(text string) string {
+	return r.wrapper(text, r.width)
+}
+
+// hangingIndent is how far wrapHanging indents the continuation lines
+const hangingIndent = 2
+
+// wrapHanging wraps text like wrap but indents the lines after the first,
+// so that a wrapped line of verse reads as one line
+func (r renderer) wrapHanging(text string) string {
+	first, rest, ok := strings.Cut(r.wrap(text), "\n")
+	if !ok || r.width <= minWidth {
+		return r.wrap(text)
+	}
+	indent := strings.Repeat(" ", hangingIndent)
+	rest = r.wrapper(strings.ReplaceAll(rest, "\n", " "), r.width-hangingIndent)
+	return first + "\n" + indent + strings.ReplaceAll(rest, "\n", "\n"+indent)
+}
+
+// highlighted applies style to each line of the already wrapped text when
+// -highlight is set, so escape codes never count toward the line width
+func (r renderer) highlighted(style, text string) string {
+	if !r.highlight {
+		return text
+	}
+	return styleLines(style, text)
+}
+
+// styleLines wraps every non-empty line of text in style
+func styleLines(style, text string) string {
+	if style == "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = style + line + Reset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// header styles s as a chapter/verse header unless plain headers were requested
+func (r renderer) header(s string) string {
+	if r.plainHeader {
+		return s
+	}
+	return HeaderStyle + s + Reset
+}
+
+// resolveTranslation maps a translation source to the text and author it
+// holds for s. ok is false when the source is unknown or its text is empty.
+func resolveTranslation(s Sloka, source string) (text, author string, ok bool) {
+	switch source {
+	case Siva:
+		text, author = s.Siva.Et, s.Siva.Author
+	case Purohit:
+		text, author = s.Purohit.Et, s.Purohit.Author
+	case Adi:
+		text, author = s.Adi.Et, s.Adi.Author
+	case San:
+		text, author = s.San.Et, s.San.Author
+	case Tej:
+		text, author = s.Tej.Ht, s.Tej.Author
+	case Chinmay:
+		text, author = s.Chinmay.Hc, s.Chinmay.Author
+	}
+	return text, author, strings.TrimSpace(text) != ""
+}
+
+// transliteration returns the romanized text of s: the dataset's own unless
+// a -scheme is set, in which case the Sanskrit is transliterated in it
+func (r renderer) transliteration(s Sloka) string {
+	if r.scheme == "" {
+		return s.Transliteration
+	}
+	text, err := transliterate(s.Slok, r.scheme)
+	if err != nil {
+		return s.Transliteration
+	}
+	return text
+}
+
+// translation returns the text and author of the active source for s
+func (r renderer) translation(s Sloka) (text, author string) {
+	text, author, _ = resolveTranslation(s, r.source)
+
+	// sanitize translations that carry markup
+	if r.stripHTML && hasHTML(text) {
+		text = stripHTML(text)
+	}
+	return text, author
+}
+
+// TranslationJSON is a translation text with its author
+type TranslationJSON struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+}
+
+// VerseJSON is the -json representation of a verse
+type VerseJSON struct {
+	ID              string `json:"id"`
+	Chapter         int    `json:"chapter"`
+	Verse           int    `json:"verse"`
+	Sanskrit        string `json:"sanskrit"`
+	Transliteration string `json:"transliteration"`
+	Source          string `json:"source"`
+	Translation     string `json:"translation"`
+	Author          string `json:"author"`
+	// ChapterInfo is the chapter metadata, included with -chapter-info
+	ChapterInfo *Chapter `json:"chapter_info,omitempty"`
+	// Translations holds every source keyed by name when requested; sources
+	// without text for the verse are included with an empty text
+	Translations map[string]TranslationJSON `json:"translations,omitempty"`
+	// Commentary is the source's commentary, included with -commentary
+	Commentary string `json:"commentary,omitempty"`
+	// WordMeanings holds the word-by-word glosses, included with -word-meanings
+	WordMeanings []gita.WordMeaning `json:"word_meanings,omitempty"`
+	// Padapatha holds the words of each Sanskrit line, included with -split
+	Padapatha []string `json:"padapatha,omitempty"`
+	// Related holds the ids of the related verses, included with -related
+	Related []string `json:"related,omitempty"`
+}
+
+// verseJSON builds the JSON representation of s for the active source
+func (r renderer) verseJSON(s Sloka, all bool) VerseJSON {
+	r = r.resolve(s)
+	text, author := r.translation(s)
+	v := VerseJSON{
+		ID:              s.ID,
+		Chapter:         s.Chapter,
+		Verse:           s.Verse,
+		Sanskrit:        s.Slok,
+		Transliteration: r.transliteration(s),
+		Source:          r.source,
+		Translation:     text,
+		Author:          author,
+	}
+	if all {
+		v.Translations = make(map[string]TranslationJSON, len(translators))
+		for _, t := range translators {
+			c := r
+			c.source = t.Key
+			text, author := c.translation(s)
+			v.Translations[t.Key] = TranslationJSON{Text: text, Author: author}
+		}
+	}
+	if r.commentary {
+		v.Commentary, _ = r.commentaryText(s)
+	}
+	if r.wordMeanings {
+		v.WordMeanings = s.WordMeanings()
+	}
+	if r.split {
+		v.Padapatha = s.PadaLines()
+	}
+	if r.related != nil {
+		for _, rel := range r.related.Related(s) {
+			v.Related = append(v.Related, rel.ID)
+		}
+	}
+	return v
+}
+
+// loadMerged returns the embedded dataset of text with the dataset file at
+// path laid over it
+func loadMerged(path, text string) (*gita.Gita, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	book, err := gita.Open(text)
+	if err != nil {
+		return nil, err
+	}
+	return book.Merge(data)
+}
+
+// trackViews adds slokas to the usage statistics as shown today in the time
+// zone of now, warning when the record cannot be updated
+func trackViews(slokas []Sloka, now time.Time) {
+	today := time.Now().In(now.Location()).Format(time.DateOnly)
+	if err := recordViews(slokas, today); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update the usage statistics: %v\n", err)
+	}
+}
+
+// textNames returns the names of the embedded texts, for -text
+func textNames() []string {
+	var names []string
+	for _, t := range gita.Texts() {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// ErrorJSON is written to stderr in -json mode when no verse matches or the
+// data cannot be loaded, with the exit status as its code
+type ErrorJSON struct {
+	Error string `json:"error"`
+	Code  int    `json:"code,omitempty"`
+}
+
+// notFound reports that no verse matched and exits with exitNotFound
+func notFound(asJSON bool, msg string) {
+	fail(asJSON, exitNotFound, msg)
+}
+
+// fail reports msg on stderr, as JSON in -json mode, and exits with code
+func fail(asJSON bool, code int, msg string) {
+	if asJSON {
+		json.NewEncoder(os.Stderr).Encode(ErrorJSON{Error: msg, Code: code})
+	} else {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+	os.Exit(code)
+}
+
+// chapterInfo writes the chapter name, translation and meaning
+func (r renderer) chapterInfo(w io.Writer, chapter Chapter) {
+	name := firstNonEmpty(chapter.Name, chapterName(chapter, r.lang))
+	fmt.Fprintln(w, r.header(fmt.Sprintf("%s %d: %s", label("Chapter", r.lang), chapter.ChapterNumber, name)))
+	if alt := chapterName(chapter, r.lang); alt != name {
+		fmt.Fprintf(w, "(%s)\n", alt)
+	}
+	if meaning := localized(chapter.Meaning.En, chapter.Meaning.Hi, r.lang); meaning != "" {
+		fmt.Fprintln(w, r.wrap(label("Meaning", r.lang)+": "+meaning))
+	}
+	if r.summary {
+		if summary := localized(chapter.Summary.En, chapter.Summary.Hi, r.lang); summary != "" {
+			fmt.Fprintln(w)
+			for _, paragraph := range strings.Split(summary, "\n") {
+				if strings.TrimSpace(paragraph) != "" {
+					fmt.Fprintln(w, r.wrap(paragraph))
+				}
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// sloka writes the verse header, Sanskrit, transliteration and translation
+func (r renderer) sloka(w io.Writer, s Sloka) {
+	r = r.resolve(s)
+
+	// display chapter and verse header
+	fmt.Fprintf(w, "%s\n\n", r.header(fmt.Sprintf("%s %d, %s %d", label("Chapter", r.lang), s.Chapter, label("Verse", r.lang), s.Verse)))
+
+	if r.commentaryOnly {
+		if text, ok := r.commentaryText(s); ok {
+			fmt.Fprintln(w, styleLines(CommentaryStyle, r.wrap(cleanTranslation(text))))
+			fmt.Fprintf(w, "%s(%s)%s\n", Dim, r.commentaryAuthor(s), Reset)
+		} else {
+			fmt.Fprintf(w, "%s%s%s\n", Dim, fmt.Sprintf(tr(r.lang, "(no commentary available for %s)"), r.source), Reset)
+		}
+		r.printCitation(w, s)
+		fmt.Fprintln(w)
+		return
+	}
+
+	// print sanskrit, each line followed by its words with -split
+	var padas []string
+	if r.split {
+		padas = s.PadaLines()
+	}
+	n := 0
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if strings.TrimSpace(line) != "" {
+			fmt.Fprintln(w, r.highlighted(SanskritStyle, r.wrap(strings.TrimSpace(line))))
+			if n < len(padas) && padas[n] != "" {
+				fmt.Fprintln(w, styleLines(Dim, r.wrapHanging(padas[n])))
+			}
+			n++
+		}
+	}
+	fmt.Fprintln(w)
+
+	// print transliteration
+	for _, line := range transliterationLines(r.transliteration(s)) {
+		fmt.Fprintln(w, r.highlighted(TransliterationStyle, r.wrapHanging(line)))
+	}
+	fmt.Fprintln(w)
+
+	if r.wordMeanings {
+		r.printWordMeanings(w, s)
+	}
+
+	// print translation
+	if r.allTranslations {
+		r.allTranslationBlocks(w, s)
+		r.printCitation(w, s)
+	} else if r.bilingual {
+		r.bilingualTranslation(w, s)
+		r.printCitation(w, s)
+	} else {
+		text, author := r.translation(s)
+		if strings.TrimSpace(text) == "" {
+			fmt.Fprintf(w, "%s%s%s\n", Dim, tr(r.lang, "(no translation available for this verse)"), Reset)
+			r.printNotes(w, s)
+			fmt.Fprintln(w)
+			return
+		}
+		fmt.Fprintln(w, r.fadeIn(r.highlighted(TranslationStyle, r.wrap(text))))
+		fmt.Fprintf(w, "%s(%s)%s\n", AuthorStyle, author, Reset)
+		if r.fallbackFrom != "" {
+			fmt.Fprintf(w, "%s%s%s\n", Dim, fmt.Sprintf(tr(r.lang, "[no %s translation for this verse; showing %s]"), r.fallbackFrom, r.source), Reset)
+		}
+		if r.autoSource != "" {
+			fmt.Fprintf(w, "%s[source: %s]%s\n", Dim, r.source, Reset)
+		}
+		r.printCitation(w, s)
+		// chinmay's translation is its commentary, so it is not repeated
+		if r.commentary && r.source != Chinmay {
+			r.printCommentary(w, s)
+		}
+	}
+	r.printNotes(w, s)
+	r.printRelated(w, s)
+
+	fmt.Fprintln(w)
+}
+
+// maxWordColumn caps the width of the word column of -word-meanings so that
+// long compounds do not push every meaning to the right
+const maxWordColumn = 24
+
+// printWordMeanings writes the words of s in a column with their meanings
+// beside them, wrapped with a hanging indent, or a note that it has none
+func (r renderer) printWordMeanings(w io.Writer, s Sloka) {
+	words := s.WordMeanings()
+	if len(words) == 0 {
+		fmt.Fprintf(w, "%s(no word meanings available for this verse)%s\n\n", Dim, Reset)
+		return
+	}
+	column := 0
+	for _, word := range words {
+		column = max(column, min(textWidth(word.Word), maxWordColumn))
+	}
+	indent := strings.Repeat(" ", column+2)
+	for _, word := range words {
+		pad := max(column-textWidth(word.Word), 0)
+		meaning := r.wrapper(word.Meaning, max(r.width-column-2, minWidth/2))
+		meaning = strings.ReplaceAll(meaning, "\n", "\n"+indent)
+		if pad == 0 && textWidth(word.Word) > column {
+			// too long for the column: the meaning goes on the next line
+			fmt.Fprintf(w, "%s\n%s%s%s%s\n", r.highlighted(SanskritStyle, word.Word), indent, Dim, meaning, Reset)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s  %s%s%s\n", r.highlighted(SanskritStyle, word.Word), strings.Repeat(" ", pad), Dim, meaning, Reset)
+	}
+	fmt.Fprintln(w)
+}
+
+// citation formats the reference to s in the -cite style, "" for none
+func (r renderer) citation(s Sloka) string {
+	switch r.cite {
+	case CiteShort:
+		return fmt.Sprintf("%s %d.%d", r.text.Abbrev, s.Chapter, s.Verse)
+	case CiteLong:
+		return fmt.Sprintf("%s, Chapter %d, Verse %d", r.text.Title, s.Chapter, s.Verse)
+	}
+	return ""
+}
+
+// printCitation writes the citation of s on its own line, if one is wanted
+func (r renderer) printCitation(w io.Writer, s Sloka) {
+	if c := r.citation(s); c != "" {
+		fmt.Fprintf(w, "%s%s%s\n", Dim, c, Reset)
+	}
+}
+
+// printRelated writes the verses related to s, each cited in the form the
+// verse argument takes and followed by as much of its translation as fits
+func (r renderer) printRelated(w io.Writer, s Sloka) {
+	if r.related == nil {
+		return
+	}
+	slokas := r.related.Related(s)
+	if len(slokas) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s%s:%s\n", Dim, label("Related", r.lang), Reset)
+	for _, rel := range slokas {
+		ref := fmt.Sprintf("%s %d.%d", r.text.Abbrev, rel.Chapter, rel.Verse)
+		text, _ := r.resolve(rel).translation(rel)
+		fmt.Fprintf(w, "  %s%s%s  %s%s%s\n", Bold, ref, Reset, Dim, truncate(cleanTranslation(text), r.width-textWidth(ref)-4), Reset)
+	}
+}
+
+// commentaryText returns the commentary that accompanies the active source's
+// translation, if the source has one
+func (r renderer) commentaryText(s Sloka) (string, bool) {
+	var text string
+	switch r.source {
+	case Siva:
+		// the dataset has "?" for the commas of this commentary
+		text = strings.ReplaceAll(s.Siva.Ec, "?", ",")
+	case Chinmay:
+		text = s.Chinmay.Hc
+	default:
+		return "", false
+	}
+	if r.stripHTML && hasHTML(text) {
+		text = stripHTML(text)
+	}
+	return text, strings.TrimSpace(text) != ""
+}
+
+// printCommentary writes the labelled commentary of the active source, or a
+// note that it has none
+func (r renderer) printCommentary(w io.Writer, s Sloka) {
+	fmt.Fprintln(w)
+	text, ok := r.commentaryText(s)
+	if !ok {
+		fmt.Fprintf(w, "%s%s%s\n", Dim, fmt.Sprintf(tr(r.lang, "(no commentary available for %s)"), r.source), Reset)
+		return
+	}
+	fmt.Fprintf(w, "%sCommentary%s\n", Bold, Reset)
+	fmt.Fprintln(w, styleLines(CommentaryStyle, r.wrap(cleanTranslation(text))))
+}
+
+// commentaryAuthor returns the author of the active source's commentary
+func (r renderer) commentaryAuthor(s Sloka) string {
+	_, author, _ := resolveTranslation(s, r.source)
+	return author
+}
+
+// allTranslationBlocks writes every non-empty translation of s, each
+// preceded by its source key and author; sources without text are skipped.
+// In blind mode the blocks are shuffled and labelled "Source A", "Source B",
+// ... with the key printed only on reveal.
+func (r renderer) allTranslationBlocks(w io.Writer, s Sloka) {
+	blocks := r.comparedTranslations(s)
+	if r.blind {
+		r.rng.Shuffle(len(blocks), func(i, j int) { blocks[i], blocks[j] = blocks[j], blocks[i] })
+	}
+
+	for i, b := range blocks {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if r.blind {
+			fmt.Fprintf(w, "%sSource %c%s\n", Bold, 'A'+i, Reset)
+		} else {
+			fmt.Fprintf(w, "%s[%s]%s %s%s%s\n", Bold, b.source, Reset, AuthorStyle, b.author, Reset)
+		}
+		fmt.Fprintln(w, r.wrap(b.text))
+	}
+
+	if r.blind && r.reveal {
+		fmt.Fprintln(w)
+		for i, b := range blocks {
+			fmt.Fprintf(w, "%sSource %c: %s [%s]%s\n", Dim, 'A'+i, b.author, b.source, Reset)
+		}
+	}
+}
+
+// translationBlock is one source's translation of a verse
+type translationBlock struct{ text, author, source string }
+
+// comparedTranslations returns the non-empty translations of s from the
+// compared sources, or from every source when none were listed
+func (r renderer) comparedTranslations(s Sloka) []translationBlock {
+	var blocks []translationBlock
+	sources := r.compared
+	if sources == nil {
+		for _, t := range translators {
+			sources = append(sources, t.Key)
+		}
+	}
+	for _, source := range sources {
+		c := r
+		c.source = source
+		if text, author := c.translation(s); strings.TrimSpace(text) != "" {
+			blocks = append(blocks, translationBlock{text, author, source})
+		}
+	}
+	return blocks
+}
+
+const columnGap = 3 // spaces between side-by-side columns
+
+// bilingualTranslation writes an English and a Hindi translation in two
+// columns, falling back to whichever one the verse has
+func (r renderer) bilingualTranslation(w io.Writer, s Sloka) {
+	en, enAuthor := r.firstTranslation(s, Siva, Purohit, Adi, San)
+	hi, hiAuthor := r.firstTranslation(s, Tej, Chinmay)
+
+	switch {
+	case en == "" && hi == "":
+		fmt.Fprintf(w, "%s%s%s\n", Dim, tr(r.lang, "(no translation available)"), Reset)
+	case en == "" || hi == "":
+		text, author, missing := en, enAuthor, "Hindi"
+		if en == "" {
+			text, author, missing = hi, hiAuthor, "English"
+		}
+		fmt.Fprintln(w, r.wrap(text))
+		fmt.Fprintf(w, "%s(%s)%s\n", AuthorStyle, author, Reset)
+		fmt.Fprintf(w, "%s(no %s translation for this verse)%s\n", Dim, missing, Reset)
+	default:
+		width := (r.width - columnGap) / 2
+		left := append(strings.Split(wrapText(en, width), "\n"), Dim+"("+enAuthor+")"+Reset)
+		right := append(strings.Split(wrapText(hi, width), "\n"), Dim+"("+hiAuthor+")"+Reset)
+		fmt.Fprint(w, columns(left, right, width))
+	}
+}
+
+// firstTranslation returns the first non-empty translation among sources
+func (r renderer) firstTranslation(s Sloka, sources ...string) (text, author string) {
+	for _, source := range sources {
+		r.source = source
+		if text, author = r.translation(s); strings.TrimSpace(text) != "" {
+			return text, author
+		}
+	}
+	return "", ""
+}
+
+// ansiEscape matches ANSI SGR sequences
+var ansiEscape = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// spelledEscape matches SGR sequences written out as text, such as \033[1m,
+// \e[0m or \x1b[2m
+var spelledEscape = regexp.MustCompile(`\\(033|e|x1[bB])\[[0-9;]*m`)
+
+// plainText drops the blank lines around text and collapses runs of blank
+// lines into one, keeping a single blank line between blocks
+func plainText(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		blank = false
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// visibleWidth returns the column width of s ignoring ANSI escape sequences
+func visibleWidth(s string) int {
+	return textWidth(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// columns lays out two lists of lines side by side, padding the left column to width
+func columns(left, right []string, width int) string {
+	var b strings.Builder
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		if r == "" {
+			b.WriteString(l + "\n")
+			continue
+		}
+		pad := width - visibleWidth(l)
+		if pad < 0 {
+			pad = 0
+		}
+		b.WriteString(l + strings.Repeat(" ", pad+columnGap) + r + "\n")
+	}
+	return b.String()
+}
+
+// parseRange parses "N" or "START-END" into an inclusive range
+func parseRange(value string) (start, end int, err error) {
+	first, second, isRange := strings.Cut(value, "-")
+	start, err = strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a number", first)
+	}
+	end = start
+	if isRange {
+		end, err = strconv.Atoi(strings.TrimSpace(second))
+		if err != nil {
+			return 0, 0, fmt.Errorf("%q is not a number", second)
+		}
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("range end %d is before start %d", end, start)
+	}
+	return start, end, nil
+}
+
+// isTerminal reports whether f is connected to a terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// page writes text through $GITASAY_PAGER or $PAGER (default "less -R")
+// when stdout is a terminal
+func page(text string) {
+	if !isTerminal(os.Stdout) {
+		fmt.Print(text)
+		return
+	}
+	pager := strings.Fields(firstNonEmpty(os.Getenv("GITASAY_PAGER"), os.Getenv("PAGER")))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		// no usable pager, print directly
+		fmt.Print(text)
+		return
+	}
+	cmd.Wait()
+}
+
+// transliterationLines splits a transliteration into its original lines,
+// dropping the trailing "." that stands for the danda. Periods inside words
+// mark an avagraha (e.g. "saṅgo.astvakarmaṇi") and are kept.
+func transliterationLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line), "."))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// inOrder returns a copy of slokas sorted by chapter and verse, since the
+// dataset does not list them strictly in order
+func inOrder(slokas []Sloka) []Sloka {
+	ordered := append([]Sloka(nil), slokas...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Chapter != ordered[j].Chapter {
+			return ordered[i].Chapter < ordered[j].Chapter
+		}
+		return ordered[i].Verse < ordered[j].Verse
+	})
+	return ordered
 }
@@ -0,0 +1,671 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// TestMain runs gitasay itself instead of the tests when runMain starts the
+// test binary as a child process
+func TestMain(m *testing.M) {
+	if os.Getenv("GITASAY_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs gitasay with args in a child process, with its config, state
+// and cache in a fresh directory, and returns what it wrote and its exit code
+func runMain(t *testing.T, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	return runMainIn(t, t.TempDir(), args...)
+}
+
+// runMainIn is runMain with the config, state and cache in dir, so that
+// several runs share them
+func runMainIn(t *testing.T, dir string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GITASAY_TEST_MAIN=1", "NO_COLOR=1",
+		"XDG_CONFIG_HOME="+dir, "XDG_STATE_HOME="+dir, "XDG_CACHE_HOME="+dir)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	var exit *exec.ExitError
+	switch {
+	case errors.As(err, &exit):
+		code = exit.ExitCode()
+	case err != nil:
+		t.Fatalf("running gitasay %q: %v", args, err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+// testBook returns the embedded Bhagavad Gita
+func testBook(t testing.TB) *gita.Gita {
+	t.Helper()
+	book, err := gita.Load()
+	if err != nil {
+		t.Fatalf("loading the embedded data: %v", err)
+	}
+	return book
+}
+
+// testSloka returns the verse at chapter and verse of book
+func testSloka(t testing.TB, book *gita.Gita, chapter, verse int) Sloka {
+	t.Helper()
+	s, ok := book.Get(chapter, verse)
+	if !ok {
+		t.Fatalf("no verse %d.%d", chapter, verse)
+	}
+	return s
+}
+
+// testRenderer returns the renderer of gitasay's defaults: Swami Sivananda's
+// translation, wrapped greedily at the width of a pipe
+func testRenderer(book *gita.Gita) renderer {
+	return renderer{text: book.Info(), source: Siva, wrapper: wrapText, width: displayWidth,
+		stripHTML: true, lang: "en", cite: CiteNone}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"", 10, ""},
+		{"one two three four", 9, "one two\nthree\nfour"},
+		{"one  two\n three", 20, "one two three"},
+		{"unbreakable", 4, "unbreakable"},
+		{"It ends. Then it starts.", 40, "It ends.\nThen it starts."},
+		{"See 2.47 and 18.66 here", 40, "See 2.47 and 18.66 here"},
+	}
+	for _, tt := range tests {
+		if got := wrapText(tt.in, tt.width); got != tt.want {
+			t.Errorf("wrapText(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestJustify(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"a b c\nd e", 7, "a  b  c\nd e"},
+		// the leftmost gaps take the remainder
+		{"ab cd ef\ngh", 11, "ab   cd  ef\ngh"},
+		// the last line of a sentence keeps its ragged end
+		{"one two.\nThree four", 10, "one two.\nThree four"},
+		// the gaps would more than quadruple
+		{"a b\nc", 20, "a b\nc"},
+		{"abc\ndef", 10, "abc\ndef"},
+		{"a b c", 9, "a b c"},
+	}
+	for _, tt := range tests {
+		if got := justify(tt.in, tt.width); got != tt.want {
+			t.Errorf("justify(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestJustifyFillsLines(t *testing.T) {
+	s := testSloka(t, testBook(t), 2, 47)
+	text := cleanTranslation(s.Siva.Ec)
+	const width = 50
+	lines := strings.Split(justify(wrapText(text, width), width), "\n")
+	for i, line := range lines[:len(lines)-1] {
+		if w := textWidth(line); w > width {
+			t.Errorf("line %d %q is %d columns, more than %d", i, line, w, width)
+		}
+	}
+	if got, want := strings.Fields(strings.Join(lines, " ")), strings.Fields(text); !slices.Equal(got, want) {
+		t.Errorf("justify changed the words:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestWrapHanging(t *testing.T) {
+	line := "karmaṇyevādhikāraste mā phaleṣu kadācana mā karmaphalaheturbhūrmā te"
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{80, line},
+		{30, "karmaṇyevādhikāraste mā\n  phaleṣu kadācana mā\n  karmaphalaheturbhūrmā te"},
+		// too narrow to give up columns to the indent
+		{minWidth, wrapText(line, minWidth)},
+	}
+	for _, tt := range tests {
+		r := renderer{wrapper: wrapText, width: tt.width}
+		if got := r.wrapHanging(line); got != tt.want {
+			t.Errorf("width %d: wrapHanging = %q, want %q", tt.width, got, tt.want)
+		}
+	}
+}
+
+// benchmarkText is the translations of chapter 2, a long run of sentences
+func benchmarkText(b *testing.B) string {
+	b.Helper()
+	book := testBook(b)
+	var parts []string
+	for _, s := range book.Verses(2) {
+		parts = append(parts, cleanTranslation(s.Siva.Et))
+	}
+	return strings.Join(parts, " ")
+}
+
+func BenchmarkWrapText(b *testing.B) {
+	text := benchmarkText(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wrapText(text, displayWidth)
+	}
+}
+
+func BenchmarkWrapBalanced(b *testing.B) {
+	text := benchmarkText(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wrapBalanced(text, displayWidth)
+	}
+}
+
+func BenchmarkJustify(b *testing.B) {
+	wrapped := wrapText(benchmarkText(b), displayWidth)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		justify(wrapped, displayWidth)
+	}
+}
+
+func BenchmarkHyphenate(b *testing.B) {
+	text := benchmarkText(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hyphenate(text, minWidth)
+	}
+}
+
+func TestWeeklyBoundary(t *testing.T) {
+	slokas := testBook(t).Data().Slokas
+	days := []struct {
+		date string
+		week string
+	}{
+		{"2024-12-29", "2024-W52"}, // Sunday
+		{"2024-12-30", "2025-W01"}, // Monday, the first day of ISO week 1
+		{"2025-01-05", "2025-W01"}, // Sunday of the same week
+		{"2025-01-06", "2025-W02"}, // Monday
+	}
+	ids := make(map[string]string)
+	for _, d := range days {
+		day, err := time.Parse(time.DateOnly, d.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := weekKey(day); got != d.week {
+			t.Errorf("weekKey(%s) = %s, want %s", d.date, got, d.week)
+		}
+		stdout, stderr, code := runMain(t, "-weekly", "-date", d.date, "-format", "json")
+		if code != 0 {
+			t.Fatalf("-weekly -date %s: exit %d: %s", d.date, code, stderr)
+		}
+		var v VerseJSON
+		if err := json.Unmarshal([]byte(stdout), &v); err != nil {
+			t.Fatalf("-weekly -date %s: %v\n%s", d.date, err, stdout)
+		}
+		if want := slokas[periodIndex(d.week, len(slokas))].ID; v.ID != want {
+			t.Errorf("-weekly -date %s shows %s, want %s", d.date, v.ID, want)
+		}
+		if id, ok := ids[d.week]; ok && id != v.ID {
+			t.Errorf("week %s shows both %s and %s", d.week, id, v.ID)
+		}
+		ids[d.week] = v.ID
+	}
+	// 2024-W52 and 2025-W01 happen to share a verse
+	if ids["2025-W01"] == ids["2025-W02"] {
+		t.Errorf("the verse did not change on Monday: both weeks show %s", ids["2025-W02"])
+	}
+}
+
+func TestDiagnosticsOnStderr(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"-c", "99"}, exitNotFound},
+		{[]string{"-c", "2", "-v", "99"}, exitNotFound},
+		{[]string{"-id", "BG99.1"}, exitNotFound},
+		{[]string{"-json", "-c", "99"}, exitNotFound},
+		{[]string{"-format", "nope"}, exitUsage},
+		{[]string{"-source", "nope"}, exitUsage},
+		{[]string{"-c", "2", "-v", "0"}, exitUsage},
+		{[]string{"2.0"}, exitUsage},
+		{[]string{"-no-such-flag"}, exitUsage},
+	}
+	for _, tt := range tests {
+		stdout, stderr, code := runMain(t, tt.args...)
+		if code != tt.code {
+			t.Errorf("%q: exit %d, want %d", tt.args, code, tt.code)
+		}
+		if stdout != "" {
+			t.Errorf("%q: wrote %q to stdout, want nothing", tt.args, stdout)
+		}
+		if stderr == "" {
+			t.Errorf("%q: wrote nothing to stderr", tt.args)
+		}
+	}
+
+	// and a verse goes to stdout alone
+	stdout, stderr, code := runMain(t, "-c", "2", "-v", "47")
+	if code != 0 || stderr != "" || !strings.Contains(stdout, "2.47") {
+		t.Errorf("-c 2 -v 47: exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestSentenceBreak(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // wrapText of in at a width that never wraps
+	}{
+		{"He fought. Then he rested.", "He fought.\nThen he rested."},
+		{"Is it so? Yes! It is.", "Is it so?\nYes!\nIt is."},
+		// not followed by the start of a sentence
+		{"the end. and more", "the end. and more"},
+		{"the end. (see above)", "the end. (see above)"},
+		// abbreviations and initialisms
+		{"Sri. Krishna spoke", "Sri. Krishna spoke"},
+		{"Mr. Arjuna and Dr. Sanjaya", "Mr. Arjuna and Dr. Sanjaya"},
+		{"cf. Ch. 2", "cf. Ch. 2"},
+		{"in 500 B.C. Vyasa wrote", "in 500 B.C. Vyasa wrote"},
+		{"that is, i.e. The Self", "that is, i.e. The Self"},
+		// ellipses
+		{"and so... Then", "and so... Then"},
+		{"and so.. Then", "and so.. Then"},
+		// quotes around the end and the start of a sentence
+		{`he said "go." Then he went`, "he said \"go.\"\nThen he went"},
+		{"he said “go.” “Then go”", "he said “go.”\n“Then go”"},
+		{"it is 'right.' 'Then'", "it is 'right.'\n'Then'"},
+		// verse numbers and periods inside a word
+		{"1.3. Behold this army", "1.3. Behold this army"},
+		{"see 2.47 Then", "see 2.47 Then"},
+		{"saṅgo.astvakarmaṇi Then", "saṅgo.astvakarmaṇi Then"},
+		// an uncased letter starts a sentence
+		{"It is said. कर्म is action", "It is said.\nकर्म is action"},
+		{"The end.", "The end."},
+	}
+	for _, tt := range tests {
+		if got := wrapText(tt.in, 200); got != tt.want {
+			t.Errorf("wrapText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		in       string
+		has      bool
+		stripped string
+	}{
+		{"Thy right is to <i>work</i> only", true, "Thy right is to work only"},
+		{"<p>Arjuna said:<br/>O Krishna</p>", true, "Arjuna said:O Krishna"},
+		{`<span class="x">duty</span> &amp; action`, true, "duty & action"},
+		{"&#8220;Yoga&#8221; &#x2014; union", true, "“Yoga” — union"},
+		{"a &lt; b", true, "a < b"},
+		// a bare < or & is text, not markup
+		{"a < b and c > d", false, "a < b and c > d"},
+		{"salt & pepper", false, "salt & pepper"},
+		{"plain text", false, "plain text"},
+	}
+	for _, tt := range tests {
+		if got := hasHTML(tt.in); got != tt.has {
+			t.Errorf("hasHTML(%q) = %v, want %v", tt.in, got, tt.has)
+		}
+		if got := stripHTML(tt.in); tt.has && got != tt.stripped {
+			t.Errorf("stripHTML(%q) = %q, want %q", tt.in, got, tt.stripped)
+		}
+	}
+}
+
+func TestTranslationStripsHTML(t *testing.T) {
+	book := testBook(t)
+	s := testSloka(t, book, 2, 47)
+	s.Siva.Et = "Thy right is to <i>work</i> only &amp; never"
+	r := testRenderer(book)
+	if text, _ := r.translation(s); text != "Thy right is to work only & never" {
+		t.Errorf("translation = %q with stripping on", text)
+	}
+	r.stripHTML = false
+	if text, _ := r.translation(s); text != s.Siva.Et {
+		t.Errorf("translation = %q with stripping off, want it untouched", text)
+	}
+}
+
+// raggedness is the sum of the squared space left at the end of each line
+func raggedness(text string, width int) int {
+	sum := 0
+	for _, line := range strings.Split(text, "\n") {
+		slack := width - textWidth(line)
+		sum += slack * slack
+	}
+	return sum
+}
+
+func TestWrapBalanced(t *testing.T) {
+	const fox = "The quick brown fox jumps over the lazy dog"
+	if got, want := wrapBalanced(fox, 20), "The quick brown\nfox jumps over\nthe lazy dog"; got != want {
+		t.Errorf("wrapBalanced = %q, want %q", got, want)
+	}
+	if got, want := wrapText(fox, 20), "The quick brown fox\njumps over the lazy\ndog"; got != want {
+		t.Errorf("wrapText = %q, want %q", got, want)
+	}
+
+	book := testBook(t)
+	for _, width := range []int{minWidth, 40, displayWidth} {
+		for _, s := range book.Verses(2) {
+			text := cleanTranslation(s.Siva.Et)
+			greedy, balanced := wrapText(text, width), wrapBalanced(text, width)
+			if !slices.Equal(strings.Fields(balanced), strings.Fields(greedy)) {
+				t.Fatalf("%d.%d at %d: the words differ\n%q\n%q", s.Chapter, s.Verse, width, balanced, greedy)
+			}
+			for _, line := range strings.Split(balanced, "\n") {
+				if w := textWidth(line); w > width && strings.Contains(line, " ") {
+					t.Errorf("%d.%d at %d: line %q is %d columns", s.Chapter, s.Verse, width, line, w)
+				}
+			}
+			if b, g := raggedness(balanced, width), raggedness(greedy, width); b > g {
+				t.Errorf("%d.%d at %d: raggedness %d, more than greedy's %d", s.Chapter, s.Verse, width, b, g)
+			}
+		}
+	}
+}
+
+func TestHyphenate(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"short words", 10, "short words"},
+		{"abcdef", 6, "abcdef"},
+		{"incomprehensible", 6, "incom- prehe- nsible"},
+		{"the incomprehensible Self", 10, "the incompreh- ensible Self"},
+		// an existing hyphen is the break
+		{"well-being", 6, "well- being"},
+		{"self-realization", 8, "self- realiza- tion"},
+		// wide characters count two columns
+		{"漢字漢字", 3, "漢- 字- 漢- 字"},
+		// a character wider than the line overflows
+		{"漢字", 1, "漢字"},
+		{"incomprehensible", 1, "incomprehensible"},
+	}
+	for _, tt := range tests {
+		if got := hyphenate(tt.in, tt.width); got != tt.want {
+			t.Errorf("hyphenate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestHyphenateFitsWidth(t *testing.T) {
+	s := testSloka(t, testBook(t), 2, 47)
+	text := cleanTranslation(s.Siva.Ec)
+	for _, width := range []int{4, 8, 12} {
+		for _, line := range strings.Split(wrapText(hyphenate(text, width), width), "\n") {
+			if w := textWidth(line); w > width {
+				t.Errorf("width %d: line %q is %d columns", width, line, w)
+			}
+		}
+	}
+}
+
+func TestAllTranslationsJSON(t *testing.T) {
+	book := testBook(t)
+	s := testSloka(t, book, 2, 47)
+	s.Adi.Et = "" // a source without text for the verse
+	r := testRenderer(book)
+
+	decode := func(all bool) map[string]any {
+		t.Helper()
+		var out strings.Builder
+		if err := (jsonFormat{r: r, book: book, allTranslations: all}).writeVerses(&out, []Sloka{s}); err != nil {
+			t.Fatal(err)
+		}
+		var v map[string]any
+		if err := json.Unmarshal([]byte(out.String()), &v); err != nil {
+			t.Fatalf("%v\n%s", err, out.String())
+		}
+		return v
+	}
+
+	if _, ok := decode(false)["translations"]; ok {
+		t.Error("translations is in the JSON without -all-translations")
+	}
+	translations, ok := decode(true)["translations"].(map[string]any)
+	if !ok {
+		t.Fatalf("translations is not an object: %v", decode(true)["translations"])
+	}
+	if len(translations) != len(translators) {
+		t.Errorf("%d translations, want one per translator, %d", len(translations), len(translators))
+	}
+	for _, tr := range translators {
+		entry, ok := translations[tr.Key].(map[string]any)
+		if !ok {
+			t.Errorf("translations[%q] is %v, want an object", tr.Key, translations[tr.Key])
+			continue
+		}
+		text, okText := entry["text"].(string)
+		_, okAuthor := entry["author"].(string)
+		if len(entry) != 2 || !okText || !okAuthor {
+			t.Errorf("translations[%q] = %v, want a text and an author", tr.Key, entry)
+		}
+		if want, _, _ := resolveTranslation(s, tr.Key); text != want {
+			t.Errorf("translations[%q].text = %q, want %q", tr.Key, text, want)
+		}
+	}
+	if adi, _ := translations[Adi].(map[string]any); adi["text"] != "" {
+		t.Errorf("translations[%q].text = %v, want an empty text", Adi, adi["text"])
+	}
+}
+
+func TestTransliterationLines(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{
+			"karmaṇyevādhikāraste mā phaleṣu kadācana .\nmā karmaphalaheturbhūrmā te saṅgo.astvakarmaṇi ||2-47||",
+			[]string{"karmaṇyevādhikāraste mā phaleṣu kadācana", "mā karmaphalaheturbhūrmā te saṅgo.astvakarmaṇi ||2-47||"},
+		},
+		{
+			"dhṛtarāṣṭra uvāca .\ndharmakṣetre kurukṣetre samavetā yuyutsavaḥ .\nmāmakāḥ pāṇḍavāścaiva kimakurvata sañjaya ||1-1||",
+			[]string{"dhṛtarāṣṭra uvāca", "dharmakṣetre kurukṣetre samavetā yuyutsavaḥ", "māmakāḥ pāṇḍavāścaiva kimakurvata sañjaya ||1-1||"},
+		},
+		// a danda without the space, and blank lines
+		{"yadā yadā hi dharmasya.\n\n  abhyutthānam ..  \n", []string{"yadā yadā hi dharmasya", "abhyutthānam"}},
+		// periods inside a line do not split it
+		{"so.aham asmi . tat tvam asi .", []string{"so.aham asmi . tat tvam asi"}},
+		{"verse 1.3 of the text", []string{"verse 1.3 of the text"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := transliterationLines(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("transliterationLines(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNoteCitations(t *testing.T) {
+	dir := t.TempDir()
+	adds := [][]string{
+		{"note", "add", "BG 2:47", "first"},
+		{"note", "add", "2:47", "second"},
+		{"note", "add", "BG", "18:66", "third"},
+		{"note", "add", "bg18.66", "fourth"},
+	}
+	for _, args := range adds {
+		if _, stderr, code := runMainIn(t, dir, args...); code != 0 {
+			t.Fatalf("%q: exit %d: %s", args, code, stderr)
+		}
+	}
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"2.47", []string{"first", "second"}},
+		{"BG 18:66", []string{"third", "fourth"}},
+	}
+	for _, tt := range tests {
+		stdout, stderr, code := runMainIn(t, dir, "note", "show", tt.ref)
+		if code != 0 {
+			t.Fatalf("note show %s: exit %d: %s", tt.ref, code, stderr)
+		}
+		for _, text := range tt.want {
+			if !strings.Contains(stdout, text) {
+				t.Errorf("note show %s: no note %q in\n%s", tt.ref, text, stdout)
+			}
+		}
+	}
+	if _, _, code := runMainIn(t, dir, "note", "add", "BG 2:99", "missing"); code != exitNotFound {
+		t.Errorf("note add BG 2:99: exit %d, want %d", code, exitNotFound)
+	}
+}
+
+func TestParseCitation(t *testing.T) {
+	book := testBook(t)
+	tests := []struct {
+		in   string
+		want []string // the ids of the cited verses
+		err  string
+	}{
+		{"2.47", []string{"BG2.47"}, ""},
+		{"2:47", []string{"BG2.47"}, ""},
+		{"bg2.47", []string{"BG2.47"}, ""},
+		{"BG 18:66", []string{"BG18.66"}, ""},
+		{" 18.66 ", []string{"BG18.66"}, ""},
+		{"2.47-49", []string{"BG2.47", "BG2.48", "BG2.49"}, ""},
+		{"2.20-2.25", []string{"BG2.20", "BG2.21", "BG2.22", "BG2.23", "BG2.24", "BG2.25"}, ""},
+		{"2.71-99", []string{"BG2.71", "BG2.72"}, ""},
+		{"2.0", nil, "invalid citation"},
+		{"0.1", nil, "invalid citation"},
+		{"2.", nil, "invalid citation"},
+		{".5", nil, "invalid citation"},
+		{"2.+5", nil, "invalid citation"},
+		{"2.49-47", nil, "invalid citation"},
+		{"2-3", nil, "invalid citation"},
+		{"2.20-3.25", nil, "invalid citation"},
+		{"karma", nil, "invalid citation"},
+		{"", nil, "invalid citation"},
+		{"19.1", nil, errNoVerse.Error()},
+		{"2.99", nil, errNoVerse.Error()},
+	}
+	for _, tt := range tests {
+		slokas, err := parseCitation(book, tt.in)
+		var got []string
+		for _, s := range slokas {
+			got = append(got, s.ID)
+		}
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("parseCitation(%q): %v", tt.in, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("parseCitation(%q) = %q, %v; want an error %q", tt.in, got, err, tt.err)
+		case !slices.Equal(got, tt.want):
+			t.Errorf("parseCitation(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// a bare chapter cites all of its verses
+	if slokas, err := parseCitation(book, "12"); err != nil || len(slokas) != 20 {
+		t.Errorf("parseCitation(\"12\") = %d verses, %v; want the 20 of chapter 12", len(slokas), err)
+	}
+}
+
+func TestSearchJSON(t *testing.T) {
+	stdout, stderr, code := runMain(t, "-search", "balanced", "-json")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var verses []VerseJSON
+	if err := json.Unmarshal([]byte(stdout), &verses); err != nil {
+		t.Fatalf("%v\n%s", err, stdout)
+	}
+	if len(verses) < 2 {
+		t.Fatalf("%d matches, want several", len(verses))
+	}
+	for _, v := range verses {
+		if v.ID == "" || !strings.Contains(strings.ToLower(v.Translation), "balanced") {
+			t.Errorf("match %s: translation %q does not contain the term", v.ID, v.Translation)
+		}
+	}
+
+	// -limit caps the array too
+	stdout, _, _ = runMain(t, "-search", "balanced", "-json", "-limit", "2")
+	if err := json.Unmarshal([]byte(stdout), &verses); err != nil || len(verses) != 2 {
+		t.Errorf("-limit 2: %d matches, %v; want 2", len(verses), err)
+	}
+}
+
+func TestUTF16LE(t *testing.T) {
+	got := utf16LE("कर्म a")
+	want := []byte{0xff, 0xfe, 0x15, 0x09, 0x30, 0x09, 0x4d, 0x09, 0x2e, 0x09, ' ', 0, 'a', 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("utf16LE = % x, want % x", got, want)
+	}
+}
+
+func TestResolveTranslation(t *testing.T) {
+	s := testSloka(t, testBook(t), 2, 47)
+	tests := []struct {
+		source string
+		prefix string // the start of the text
+		author string
+		ok     bool
+	}{
+		{Siva, "2.47 Thy right is to work only", "Swami Sivananda", true},
+		{Purohit, "2.47 But thou hast only the right", "Shri Purohit Swami", true},
+		{Adi, "2.47 To work alone you have the right", "Swami Adidevananda", true},
+		{San, "2.47. Let your claim lie on action alone", "Dr.S.Sankaranarayan", true},
+		{Tej, "।।2.47।। कर्म करने मात्र में", "Swami Tejomayananda", true},
+		{Chinmay, "।।2.47।। वेद प्रतिपादित", "Swami Chinmayananda", true},
+		{"nope", "", "", false},
+	}
+	for _, tt := range tests {
+		text, author, ok := resolveTranslation(s, tt.source)
+		if !strings.HasPrefix(text, tt.prefix) || author != tt.author || ok != tt.ok {
+			t.Errorf("resolveTranslation(2.47, %s) = %.40q, %q, %v; want %q…, %q, %v",
+				tt.source, text, author, ok, tt.prefix, tt.author, tt.ok)
+		}
+	}
+
+	// a verse missing some translations
+	s.Siva.Et, s.Purohit.Et, s.Tej.Ht = "", " \n", ""
+	for _, source := range []string{Siva, Purohit, Tej} {
+		if _, _, ok := resolveTranslation(s, source); ok {
+			t.Errorf("resolveTranslation(%s) is ok for an empty text", source)
+		}
+	}
+	r := testRenderer(testBook(t)).resolve(s)
+	if r.source != Adi || r.fallbackFrom != Siva {
+		t.Errorf("resolve falls back from %q to %q, want from %q to %q", r.fallbackFrom, r.source, Siva, Adi)
+	}
+	if text, author := r.translation(s); !strings.HasPrefix(text, "2.47 To work alone") || author != "Swami Adidevananda" {
+		t.Errorf("fallback translation = %.40q by %q", text, author)
+	}
+	r = testRenderer(testBook(t))
+	r.source = Tej
+	if r = r.resolve(s); r.source != Adi {
+		t.Errorf("resolve falls back from %s to %s, want %s, the first source with text", Tej, r.source, Adi)
+	}
+}
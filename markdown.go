@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownChapter writes the chapter as a level-2 heading with its meaning
+func (r renderer) markdownChapter(w io.Writer, chapter Chapter) {
+	name := firstNonEmpty(chapter.Name, chapterName(chapter, r.lang))
+	fmt.Fprintf(w, "## %s %d: %s\n\n", label("Chapter", r.lang), chapter.ChapterNumber, name)
+	if alt := chapterName(chapter, r.lang); alt != name {
+		fmt.Fprintf(w, "*%s*\n\n", alt)
+	}
+	if meaning := localized(chapter.Meaning.En, chapter.Meaning.Hi, r.lang); meaning != "" {
+		fmt.Fprintf(w, "%s: %s\n\n", label("Meaning", r.lang), meaning)
+	}
+}
+
+// markdown writes s as Markdown: a heading, the Sanskrit as a blockquote,
+// the transliteration in italics and the translation, or each compared
+// translation, with its attribution.
+// Text is not hard-wrapped so renderers can reflow it.
+func (r renderer) markdown(w io.Writer, s Sloka) {
+	r = r.resolve(s)
+	fmt.Fprintf(w, "### %s %d, %s %d\n\n", label("Chapter", r.lang), s.Chapter, label("Verse", r.lang), s.Verse)
+
+	var quoted []string
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			quoted = append(quoted, "> "+line)
+		}
+	}
+	// two trailing spaces keep each verse line on its own line
+	fmt.Fprintf(w, "%s\n\n", strings.Join(quoted, "  \n"))
+
+	var italic []string
+	for _, line := range transliterationLines(r.transliteration(s)) {
+		italic = append(italic, "*"+line+"*")
+	}
+	fmt.Fprintf(w, "%s\n\n", strings.Join(italic, "  \n"))
+
+	if r.allTranslations {
+		for _, b := range r.comparedTranslations(s) {
+			fmt.Fprintf(w, "%s\n\n", strings.Join(strings.Fields(b.text), " "))
+			fmt.Fprintf(w, "— %s\n\n", b.author)
+		}
+		return
+	}
+	text, author := r.translation(s)
+	fmt.Fprintf(w, "%s\n\n", strings.Join(strings.Fields(text), " "))
+	fmt.Fprintf(w, "— %s\n\n", author)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// note is a personal note on a verse
+type note struct {
+	Date string `json:"date"` // day it was written, e.g. "2024-02-14"
+	Text string `json:"text"`
+}
+
+// notesPath returns the file holding the notes
+func notesPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "notes.json"), nil
+}
+
+// loadNotes returns the notes keyed by sloka id, oldest first. A missing
+// file means no notes.
+func loadNotes() (map[string][]note, error) {
+	path, err := notesPath()
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string][]note)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return notes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return notes, nil
+}
+
+// saveNotes replaces the notes with notes
+func saveNotes(notes map[string][]note) error {
+	path, err := notesPath()
+	if err != nil {
+		return err
+	}
+	return writeState(path, notes)
+}
+
+// printNotes writes the notes on s, dimmed, under the verse
+func (r renderer) printNotes(w io.Writer, s Sloka) {
+	for _, n := range r.notes[s.ID] {
+		fmt.Fprintf(w, "%s\n", styleLines(Dim, r.wrap(fmt.Sprintf("%s (%s): %s", label("Note", r.lang), n.Date, n.Text))))
+	}
+}
+
+// printNoteList writes the verses with notes in book order, each reference
+// in bold followed by its notes
+func printNoteList(w io.Writer, book gita.Scripture, notes map[string][]note, r renderer) {
+	for _, s := range inOrder(book.Data().Slokas) {
+		if len(notes[s.ID]) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %d.%d%s\n", Bold, r.text.Abbrev, s.Chapter, s.Verse, Reset)
+		for _, n := range notes[s.ID] {
+			fmt.Fprintf(w, "  %s  %s\n", n.Date, truncate(n.Text, r.width-len(n.Date)-4))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// notifyBodyWidth is the most columns of translation a notification shows;
+// notification daemons cut or hide longer bodies
+const notifyBodyWidth = 240
+
+// notifyScript shows a Windows balloon notification with the title and body
+// passed in the environment, which avoids quoting them for PowerShell
+const notifyScript = `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, $env:GITASAY_TITLE, $env:GITASAY_BODY, 'None')
+Start-Sleep -Seconds 10
+$n.Dispose()`
+
+// notifyCommands lists the notification tools to try on each platform, in
+// order of preference; TITLE and BODY stand for the notification text
+var notifyCommands = map[string][][]string{
+	"darwin": {{"osascript", "-e", "on run argv", "-e",
+		"display notification (item 2 of argv) with title (item 1 of argv)", "-e", "end run", "TITLE", "BODY"}},
+	"windows": {{"powershell", "-NoProfile", "-Command", notifyScript}},
+	"linux":   {{"notify-send", "--app-name=gitasay", "TITLE", "BODY"}},
+}
+
+// errNoNotifier is returned when no notification tool is installed
+var errNoNotifier = errors.New("no notification tool found")
+
+// sendNotification posts a desktop notification using the first available
+// tool for the platform
+func sendNotification(title, body string) error {
+	for _, args := range notifyCommands[runtime.GOOS] {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		argv := make([]string, 0, len(args))
+		for _, arg := range args {
+			switch arg {
+			case "TITLE":
+				arg = title
+			case "BODY":
+				arg = body
+			}
+			argv = append(argv, arg)
+		}
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Env = append(os.Environ(), "GITASAY_TITLE="+title, "GITASAY_BODY="+body)
+		return cmd.Run()
+	}
+	return errNoNotifier
+}
+
+// parseClock parses a time of day such as "07:00"
+func parseClock(value string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q (expected HH:MM)", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// nextAt returns the first time after now at hour:minute, today or tomorrow
+func nextAt(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// notifyLoop posts a random verse of pool as a notification, right away and
+// then every interval, or once a day at hour:minute when at is set. It runs
+// until the process is stopped and returns only if no notification tool is
+// available.
+func notifyLoop(pool []Sloka, r renderer, rng *rand.Rand, every time.Duration, at bool, hour, minute int) error {
+	next := time.Now()
+	if at {
+		next = nextAt(next, hour, minute)
+	}
+	for {
+		time.Sleep(time.Until(next))
+
+		s := pool[rng.Intn(len(pool))]
+		text, author := r.resolve(s).translation(s)
+		title := fmt.Sprintf("%s %d.%d", r.text.Title, s.Chapter, s.Verse)
+		body := fmt.Sprintf("%s\n— %s", truncate(cleanTranslation(text), notifyBodyWidth), author)
+		if err := sendNotification(title, body); errors.Is(err, errNoNotifier) {
+			return err
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not post the notification: %v\n", err)
+		}
+
+		if at {
+			next = nextAt(time.Now(), hour, minute)
+		} else {
+			next = time.Now().Add(every)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// occasionAuto is the -occasion value that picks the occasion of the day
+const occasionAuto = "auto"
+
+// occasionSearchDays bounds the search for an occasion's next day: more
+// than a year, for the years an intercalary month delays a festival
+const occasionSearchDays = 400
+
+// moments of a day at which a festival's tithi must be in force: most are
+// kept on the tithi of sunrise, some on that of noon, the evening or
+// midnight. Sunrise is taken to be 6 a.m.
+const (
+	atSunrise  = 6 * time.Hour
+	atNoon     = 12 * time.Hour
+	atEvening  = 20 * time.Hour
+	atMidnight = 24 * time.Hour
+)
+
+// occasion is a day of the calendar with verses chosen for it: the verses
+// of a chapter, or a list of verse ids
+type occasion struct {
+	name    string
+	summary string // when it falls
+	chapter int
+	ids     []string
+	on      func(day time.Time) bool // whether the day starting at day is one
+}
+
+// occasions lists the occasions, the most particular first, so the first
+// one that falls on a day is its occasion
+var occasions = []occasion{
+	{"gita-jayanti", "Shukla Ekadashi of Margashirsha, the day the Gita was spoken", 0,
+		[]string{"BG18.66", "BG2.47", "BG4.7", "BG9.22", "BG18.78"}, lunarDay(Margashirsha, 11, atSunrise)},
+	{"janmashtami", "Krishna Ashtami of Shravana, the birth of Krishna", 0,
+		[]string{"BG4.6", "BG4.7", "BG4.8", "BG4.9", "BG10.8"}, lunarDay(Shravana, 23, atMidnight)},
+	{"rama-navami", "Shukla Navami of Chaitra, the birth of Rama", 0,
+		[]string{"BG10.31"}, lunarDay(Chaitra, 9, atNoon)},
+	{"maha-shivaratri", "Krishna Chaturdashi of Magha, the night of Shiva", 0,
+		[]string{"BG10.23", "BG10.25"}, lunarDay(Magha, 29, atMidnight)},
+	{"guru-purnima", "Purnima of Ashadha, the day of the teacher", 0,
+		[]string{"BG4.1", "BG4.2", "BG4.34", "BG4.35"}, lunarDay(Ashadha, 15, atSunrise)},
+	{"diwali", "Amavasya of Ashvin, the festival of lights", 0,
+		[]string{"BG5.16", "BG10.11", "BG13.17", "BG15.12"}, lunarDay(Ashvin, 30, atEvening)},
+	{"makar-sankranti", "The sun entering Makara, the start of its northern course", 0,
+		[]string{"BG8.24"}, sankranti(9)},
+	{"ekadashi", "The eleventh tithi of each fortnight, kept with fasting", 0,
+		[]string{"BG6.16", "BG6.17", "BG9.26", "BG9.27", "BG17.14"}, tithiDay(atSunrise, 11, 26)},
+	{"purnima", "The full moon", 0,
+		[]string{"BG10.21", "BG11.19", "BG15.13"}, tithiDay(atSunrise, 15)},
+	{"amavasya", "The new moon, remembered for the ancestors", 0,
+		[]string{"BG8.25", "BG8.26", "BG9.25"}, tithiDay(atSunrise, 30)},
+	{"sunday", "Sundays", 15, nil, weekday(time.Sunday)},
+	{"monday", "Mondays", 2, nil, weekday(time.Monday)},
+	{"tuesday", "Tuesdays", 3, nil, weekday(time.Tuesday)},
+	{"wednesday", "Wednesdays", 6, nil, weekday(time.Wednesday)},
+	{"thursday", "Thursdays", 4, nil, weekday(time.Thursday)},
+	{"friday", "Fridays", 12, nil, weekday(time.Friday)},
+	{"saturday", "Saturdays", 18, nil, weekday(time.Saturday)},
+}
+
+// occasionNames returns the names -occasion accepts besides auto
+func occasionNames() []string {
+	var names []string
+	for _, o := range occasions {
+		names = append(names, o.name)
+	}
+	return names
+}
+
+// findOccasion returns the occasion called name
+func findOccasion(name string) (occasion, bool) {
+	for _, o := range occasions {
+		if o.name == name {
+			return o, true
+		}
+	}
+	return occasion{}, false
+}
+
+// startOfDay returns the midnight starting the day of t, in its location
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// occasionsOn returns the occasions falling on the day of t, the most
+// particular first
+func occasionsOn(t time.Time) []occasion {
+	day := startOfDay(t)
+	var on []occasion
+	for _, o := range occasions {
+		if o.on(day) {
+			on = append(on, o)
+		}
+	}
+	return on
+}
+
+// nextDay returns the first day from t's on which o falls, and false when
+// there is none within occasionSearchDays
+func (o occasion) nextDay(t time.Time) (time.Time, bool) {
+	day := startOfDay(t)
+	for range occasionSearchDays {
+		if o.on(day) {
+			return day, true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
+
+// slokas returns the verses of o in book, skipping ids it lacks
+func (o occasion) slokas(book gita.Scripture) []Sloka {
+	if o.chapter != 0 {
+		return inOrder(book.Verses(o.chapter))
+	}
+	var slokas []Sloka
+	for _, id := range o.ids {
+		if s, ok := book.ByID(id); ok {
+			slokas = append(slokas, s)
+		}
+	}
+	return slokas
+}
+
+// wrapTithi returns tithi n counted round the month, 1 to 30
+func wrapTithi(n int) int {
+	return (n+29)%30 + 1
+}
+
+// onTithi reports whether the day starting at day keeps tithi, in force at
+// the moment at of the day, or begun and ended between that moment of the
+// day before and of this day, so a tithi too short to be in force at any
+// such moment is kept on the day it ends
+func onTithi(day time.Time, at time.Duration, tithi int) bool {
+	t := day.Add(at)
+	now := tithiAt(t)
+	if now == tithi {
+		return true
+	}
+	return now == wrapTithi(tithi+1) && tithiAt(t.AddDate(0, 0, -1)) == wrapTithi(tithi-1)
+}
+
+// lunarDay returns the rule of a festival kept on tithi of the lunar month,
+// not in an intercalary month of that name
+func lunarDay(month, tithi int, at time.Duration) func(time.Time) bool {
+	return func(day time.Time) bool {
+		if !onTithi(day, at, tithi) {
+			return false
+		}
+		m, adhika := lunarMonthAt(day.Add(at))
+		return m == month && !adhika
+	}
+}
+
+// tithiDay returns the rule of a day kept on any of the tithis, every month
+func tithiDay(at time.Duration, tithis ...int) func(time.Time) bool {
+	return func(day time.Time) bool {
+		for _, tithi := range tithis {
+			if onTithi(day, at, tithi) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// sankranti returns the rule of the day on which the sun enters the
+// sidereal sign, 0 for Mesha to 11 for Meena
+func sankranti(sign int) func(time.Time) bool {
+	return func(day time.Time) bool {
+		return siderealSign(day) != sign && siderealSign(day.AddDate(0, 0, 1)) == sign
+	}
+}
+
+// weekday returns the rule of the days of the week that are d
+func weekday(d time.Weekday) func(time.Time) bool {
+	return func(day time.Time) bool {
+		return day.Weekday() == d
+	}
+}
+
+// OccasionJSON is an occasion with its next day
+type OccasionJSON struct {
+	Occasion string `json:"occasion"`
+	Next     string `json:"next,omitempty"` // e.g. "2024-12-11"
+	Today    bool   `json:"today"`
+	Chapter  int    `json:"chapter,omitempty"` // whose verses it shows, else it lists verses
+	Verses   int    `json:"verses"`
+	Summary  string `json:"summary"`
+}
+
+// occasionList returns every occasion with its next day from t and its
+// verse count in book, in the order auto prefers them
+func occasionList(book gita.Scripture, t time.Time) []OccasionJSON {
+	today := dayKey(t)
+	var list []OccasionJSON
+	for _, o := range occasions {
+		entry := OccasionJSON{Occasion: o.name, Chapter: o.chapter, Verses: len(o.slokas(book)), Summary: o.summary}
+		if day, ok := o.nextDay(t); ok {
+			entry.Next = dayKey(day)
+			entry.Today = entry.Next == today
+		}
+		list = append(list, entry)
+	}
+	return list
+}
+
+// printOccasions writes an aligned table of the occasions, their next day,
+// verses and when they fall, with the ones falling today in bold
+func printOccasions(w io.Writer, list []OccasionJSON) {
+	width := len("OCCASION")
+	for _, o := range list {
+		width = max(width, len(o.Occasion))
+	}
+	fmt.Fprintf(w, "%s%-*s  %-10s  %-10s  %s%s\n", Bold, width, "OCCASION", "NEXT", "VERSES", "WHEN", Reset)
+	for _, o := range list {
+		next, style, reset := o.Next, "", ""
+		if o.Today {
+			next, style, reset = "today", Bold, Reset
+		}
+		if next == "" {
+			next = "-"
+		}
+		verses := fmt.Sprintf("%d", o.Verses)
+		if o.Chapter != 0 {
+			verses = fmt.Sprintf("chapter %d", o.Chapter)
+		}
+		fmt.Fprintf(w, "%s%-*s  %-10s  %-10s  %s%s\n", style, width, o.Occasion, next, verses, o.Summary, reset)
+	}
+}
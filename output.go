@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// output is the destination of rendered verses: stdout or the -output file.
+// In buffered mode everything is kept in memory until Close, which validates
+// it and then writes it in one go, so a failure never leaves partial output.
+type output struct {
+	w        io.Writer
+	file     *os.File
+	buf      *bytes.Buffer
+	path     string
+	validate func([]byte) error
+	pager    bool // pageIfLong may send the output through the pager
+	paged    bool // the output is held for the pager
+}
+
+// openOutput opens the destination for path ("" for stdout). When buffered
+// is set, JSON output is checked for validity before anything is written.
+func openOutput(path string, buffered, isJSON bool) (*output, error) {
+	o := &output{path: path, w: os.Stdout}
+	if buffered {
+		o.buf = new(bytes.Buffer)
+		o.w = o.buf
+		if isJSON {
+			o.validate = validJSON
+		}
+		return o, nil
+	}
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		o.file, o.w = f, f
+	}
+	return o, nil
+}
+
+// Write implements io.Writer
+func (o *output) Write(p []byte) (int, error) {
+	return o.w.Write(p)
+}
+
+// toStdout reports whether output goes straight to stdout
+func (o *output) toStdout() bool {
+	return o.buf == nil && o.file == nil
+}
+
+// pageIfLong holds the output written from now on so that Close shows it
+// through the pager if it is taller than the terminal, as git does. It has
+// no effect unless the output goes straight to a terminal and the pager is
+// on.
+func (o *output) pageIfLong() {
+	if o.pager && o.toStdout() && isTerminal(os.Stdout) {
+		o.buf = new(bytes.Buffer)
+		o.w, o.paged = o.buf, true
+	}
+}
+
+// Close flushes buffered output and closes the -output file
+func (o *output) Close() error {
+	if o.file != nil {
+		return o.file.Close()
+	}
+	if o.paged {
+		text := o.buf.String()
+		if width, height := terminalSize(); screenLines(text, width) < height {
+			_, err := io.WriteString(os.Stdout, text)
+			return err
+		}
+		page(text)
+		return nil
+	}
+	if o.buf == nil {
+		return nil
+	}
+	if o.validate != nil {
+		if err := o.validate(o.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if o.path == "" {
+		_, err := os.Stdout.Write(o.buf.Bytes())
+		return err
+	}
+
+	// write next to the target and rename so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), ".gitasay-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(o.buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// validJSON checks that data is a sequence of valid JSON values
+func validJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
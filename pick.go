@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pickHeight is the most verses the built-in finder lists at once
+const pickHeight = 12
+
+// errNoPick is returned when the picker is closed without choosing a verse
+var errNoPick = errors.New("no verse picked")
+
+// pickLines returns the one-line summary of each of slokas the picker lists:
+// the citation and the start of the translation
+func pickLines(slokas []Sloka, r renderer) []string {
+	lines := make([]string, len(slokas))
+	for i, s := range slokas {
+		ref := fmt.Sprintf("%s %d.%d", r.text.Abbrev, s.Chapter, s.Verse)
+		text, _ := r.resolve(s).translation(s)
+		lines[i] = ref + "  " + truncate(cleanTranslation(text), r.width-utf8.RuneCountInString(ref)-2)
+	}
+	return lines
+}
+
+// pick lets the user choose one of lines and returns its index, with fzf
+// when it is installed and with the built-in finder otherwise
+func pick(lines []string) (int, error) {
+	if _, err := exec.LookPath("fzf"); err == nil {
+		return pickFzf(lines)
+	}
+	if !isTerminal(os.Stdin) {
+		return 0, errors.New("picking a verse needs a terminal on stdin, or fzf")
+	}
+	return pickBuiltin(os.Stderr, lines)
+}
+
+// pickFzf runs fzf on lines, each prefixed by its hidden index so the
+// choice maps back to its verse
+func pickFzf(lines []string) (int, error) {
+	var in strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&in, "%d\t%s\n", i, line)
+	}
+	cmd := exec.Command("fzf", "--delimiter=\t", "--with-nth=2..", "--prompt=Verse> ")
+	cmd.Stdin = strings.NewReader(in.String())
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+
+	// fzf exits with 1 when nothing matched and 130 when cancelled
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && (exit.ExitCode() == 1 || exit.ExitCode() == 130) {
+		return 0, errNoPick
+	} else if err != nil {
+		return 0, err
+	}
+	field, _, _ := strings.Cut(string(out), "\t")
+	i, err := strconv.Atoi(field)
+	if err != nil || i < 0 || i >= len(lines) {
+		return 0, fmt.Errorf("unexpected fzf output %q", strings.TrimSpace(string(out)))
+	}
+	return i, nil
+}
+
+// pickBuiltin is a small fzf-like finder drawn on w: typing filters lines
+// fuzzily, ↑ and ↓ (or Ctrl-P and Ctrl-N) move the selection, Enter picks
+// it and Esc or Ctrl-C cancels
+func pickBuiltin(w io.Writer, lines []string) (int, error) {
+	query, selected := "", 0
+	for {
+		matches := fuzzyFilter(lines, query)
+		selected = max(0, min(selected, len(matches)-1))
+		first := max(0, selected-pickHeight+1)
+
+		var b strings.Builder
+		b.WriteString(clearScreen)
+		fmt.Fprintf(&b, "%sVerse>%s %s\n", Bold, Reset, query)
+		fmt.Fprintf(&b, "%s%d/%d%s\n", Dim, len(matches), len(lines), Reset)
+		for i := first; i < len(matches) && i < first+pickHeight; i++ {
+			if i == selected {
+				fmt.Fprintf(&b, "%s> %s%s\n", Reverse, lines[matches[i]], Reset)
+			} else {
+				fmt.Fprintf(&b, "  %s\n", lines[matches[i]])
+			}
+		}
+		io.WriteString(w, b.String())
+
+		key, err := readKey()
+		if err != nil {
+			return 0, err
+		}
+		switch key {
+		case "\r", "\n":
+			if len(matches) > 0 {
+				io.WriteString(w, clearScreen)
+				return matches[selected], nil
+			}
+		case "\033", "\003", "\004":
+			// Esc, Ctrl-C or Ctrl-D
+			io.WriteString(w, clearScreen)
+			return 0, errNoPick
+		case "\033[A", "\020":
+			selected--
+		case "\033[B", "\016":
+			selected++
+		case "\177", "\b":
+			if _, size := utf8.DecodeLastRuneInString(query); size > 0 {
+				query = query[:len(query)-size]
+			}
+			selected = 0
+		case "\025":
+			// Ctrl-U
+			query, selected = "", 0
+		default:
+			if r, _ := utf8.DecodeRuneInString(key); unicode.IsPrint(r) {
+				query += key
+				selected = 0
+			}
+		}
+	}
+}
+
+// fuzzyFilter returns the indexes of the lines matching every word of query,
+// best first, and all of them in order for an empty query
+func fuzzyFilter(lines []string, query string) []int {
+	type match struct{ index, score int }
+	var matches []match
+	words := strings.Fields(strings.ToLower(query))
+line:
+	for i, line := range lines {
+		text := []rune(strings.ToLower(line))
+		score := 0
+		for _, word := range words {
+			s, ok := fuzzyScore(text, []rune(word))
+			if !ok {
+				continue line
+			}
+			score += s
+		}
+		matches = append(matches, match{i, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
+
+// fuzzyScore reports whether the runes of word appear in order in text and
+// scores the match by the runes skipped between them, so lower is better
+// and a contiguous match scores 0. Each start position is tried, keeping the
+// tightest match.
+func fuzzyScore(text, word []rune) (int, bool) {
+	best, found := 0, false
+	for start, r := range text {
+		if r != word[0] {
+			continue
+		}
+		score, j := 0, 1
+		for i := start + 1; i < len(text) && j < len(word); i++ {
+			if text[i] == word[j] {
+				j++
+			} else {
+				score++
+			}
+		}
+		if j == len(word) && (!found || score < best) {
+			best, found = score, true
+		}
+		if found && best == 0 {
+			break
+		}
+	}
+	return best, found
+}
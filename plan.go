@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// PlanDay is one day of a reading plan, covering the verses from Start to End
+type PlanDay struct {
+	Date   string `json:"date"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Verses int    `json:"verses"`
+}
+
+// readingPlan splits the slokas, in chapter and verse order, into days of
+// perDay verses starting at start; the last day holds the remainder
+func readingPlan(slokas []Sloka, start time.Time, perDay int) []PlanDay {
+	ordered := inOrder(slokas)
+
+	var days []PlanDay
+	for i := 0; i < len(ordered); i += perDay {
+		end := i + perDay
+		if end > len(ordered) {
+			end = len(ordered)
+		}
+		first, last := ordered[i], ordered[end-1]
+		days = append(days, PlanDay{
+			Date:   start.AddDate(0, 0, len(days)).Format(time.DateOnly),
+			Start:  fmt.Sprintf("%d.%d", first.Chapter, first.Verse),
+			End:    fmt.Sprintf("%d.%d", last.Chapter, last.Verse),
+			Verses: end - i,
+		})
+	}
+	return days
+}
+
+// writePlanCSV writes the plan as CSV with a header row
+func writePlanCSV(w io.Writer, days []PlanDay) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "start", "end", "verses"})
+	for _, d := range days {
+		cw.Write([]string{d.Date, d.Start, d.End, strconv.Itoa(d.Verses)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readingProgress is the persisted state of the plan begun with "plan
+// start": the verses, in chapter and verse order, are split evenly over
+// Days days from Start
+type readingProgress struct {
+	Start  string   `json:"start"`   // first day, e.g. "2024-01-01"
+	Days   int      `json:"days"`    // length of the plan
+	Done   []int    `json:"done"`    // days, counted from 0, whose verses were read
+	ReadOn []string `json:"read_on"` // dates on which verses were read, in order
+}
+
+// PlanStatus is the progress of the reading plan printed by "plan status"
+type PlanStatus struct {
+	Start      string  `json:"start"`
+	Days       int     `json:"days"`
+	Day        int     `json:"day"` // today's day of the plan, from 1
+	VersesRead int     `json:"verses_read"`
+	Verses     int     `json:"verses"`
+	Percent    float64 `json:"percent"`
+	Behind     int     `json:"behind"` // earlier days not read yet
+	Streak     int     `json:"streak"` // days in a row with verses read, up to today
+}
+
+// progressPath returns the file holding the reading plan's progress
+func progressPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plan.json"), nil
+}
+
+// errNoPlan is returned when no reading plan has been started
+var errNoPlan = errors.New("no reading plan started")
+
+// loadProgress returns the reading plan's progress, or errNoPlan
+func loadProgress() (readingProgress, error) {
+	var p readingProgress
+	path, err := progressPath()
+	if err != nil {
+		return p, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, errNoPlan
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("%s: %v", path, err)
+	}
+	if p.Days < 1 {
+		return p, fmt.Errorf("%s: invalid length of %d days", path, p.Days)
+	}
+	return p, nil
+}
+
+// saveProgress persists the reading plan's progress
+func saveProgress(p readingProgress) error {
+	path, err := progressPath()
+	if err != nil {
+		return err
+	}
+	return writeState(path, p)
+}
+
+// daysBetween returns the number of calendar days from the date from to the
+// date to, as YYYY-MM-DD
+func daysBetween(from, to string) (int, error) {
+	a, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return 0, err
+	}
+	b, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return 0, err
+	}
+	return int(b.Sub(a).Hours() / 24), nil
+}
+
+// planVerses returns the verses of day, counted from 0, of a plan over days
+// days, ordered being every verse in chapter and verse order
+func planVerses(ordered []Sloka, days, day int) []Sloka {
+	return ordered[day*len(ordered)/days : (day+1)*len(ordered)/days]
+}
+
+// markRead records that the verses of day were read on date
+func (p *readingProgress) markRead(day int, date string) {
+	if !slices.Contains(p.Done, day) {
+		p.Done = append(p.Done, day)
+		slices.Sort(p.Done)
+	}
+	if !slices.Contains(p.ReadOn, date) {
+		p.ReadOn = append(p.ReadOn, date)
+		slices.Sort(p.ReadOn)
+	}
+}
+
+// status summarizes p on the date today, ordered being every verse in
+// chapter and verse order
+func (p readingProgress) status(today string, ordered []Sloka) (PlanStatus, error) {
+	day, err := daysBetween(p.Start, today)
+	if err != nil {
+		return PlanStatus{}, err
+	}
+	st := PlanStatus{Start: p.Start, Days: p.Days, Day: day + 1, Verses: len(ordered)}
+	for _, done := range p.Done {
+		if done >= 0 && done < p.Days {
+			st.VersesRead += len(planVerses(ordered, p.Days, done))
+		}
+	}
+	st.Percent = 100 * float64(st.VersesRead) / float64(max(st.Verses, 1))
+	for d := 0; d < min(day, p.Days); d++ {
+		if !slices.Contains(p.Done, d) {
+			st.Behind++
+		}
+	}
+
+	// a streak is still alive until a whole day goes by without reading
+	date, _ := time.Parse(time.DateOnly, today)
+	if !slices.Contains(p.ReadOn, today) {
+		date = date.AddDate(0, 0, -1)
+	}
+	for slices.Contains(p.ReadOn, date.Format(time.DateOnly)) {
+		st.Streak++
+		date = date.AddDate(0, 0, -1)
+	}
+	return st, nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// quiz modes accepted by -quiz-mode
+const (
+	QuizReference = "reference"
+	QuizChoice    = "choice"
+	QuizRecall    = "recall"
+)
+
+// quizModes lists the -quiz-mode values
+var quizModes = []string{QuizReference, QuizChoice, QuizRecall}
+
+// quizChoices is the number of references offered in choice mode
+const quizChoices = 4
+
+// quiz asks questions about verses drawn from pool in mode and prints the
+// score at the end. Answering q, or closing stdin, ends the session early.
+func quiz(w io.Writer, book gita.Scripture, pool []Sloka, questions int, mode string, r renderer, rng *rand.Rand) error {
+	questions = min(questions, len(pool))
+	asked, correct := 0, 0
+	for _, i := range rng.Perm(len(pool))[:questions] {
+		s := pool[i]
+		fmt.Fprintf(w, "\n%s\n\n", r.header(fmt.Sprintf("Question %d of %d", asked+1, questions)))
+
+		var right bool
+		var err error
+		switch mode {
+		case QuizChoice:
+			right, err = r.askChoice(w, pool, s, rng)
+		case QuizRecall:
+			right, err = r.askRecall(w, s)
+		default:
+			right, err = r.askReference(w, book, s)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		asked++
+		if right {
+			correct++
+		}
+	}
+	fmt.Fprintf(w, "\n%s\n", r.header(fmt.Sprintf("Score: %d of %d", correct, asked)))
+	return nil
+}
+
+// errQuit ends a quiz when the answer is q
+var errQuit = errors.New("quit")
+
+// ask prompts for an answer, returning errQuit for q
+func ask(label string) (string, error) {
+	answer, err := prompt(label)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(answer, "q") {
+		return "", errQuit
+	}
+	return answer, nil
+}
+
+// questionText returns the translation of s without its verse number, which
+// would give the answer away
+func (r renderer) questionText(s Sloka) string {
+	text, _ := r.resolve(s).translation(s)
+	return r.wrap(cleanTranslation(text))
+}
+
+// askReference shows the translation of s and asks for its chapter and verse
+func (r renderer) askReference(w io.Writer, book gita.Scripture, s Sloka) (bool, error) {
+	fmt.Fprintf(w, "%s\n\n", r.questionText(s))
+	for {
+		answer, err := ask("Chapter and verse (e.g. 2.47): ")
+		if err != nil {
+			return false, err
+		}
+		guess, err := parseVerse(book, answer)
+		if err != nil && !errors.Is(err, errNoVerse) {
+			fmt.Fprintln(w, err)
+			continue
+		}
+		switch {
+		case guess.ID == s.ID:
+			fmt.Fprintln(w, "Correct!")
+			return true, nil
+		case guess.Chapter == s.Chapter:
+			fmt.Fprintf(w, "Right chapter; it is %d.%d.\n", s.Chapter, s.Verse)
+		default:
+			fmt.Fprintf(w, "It is %d.%d.\n", s.Chapter, s.Verse)
+		}
+		return false, nil
+	}
+}
+
+// askChoice shows the translation of s and offers its reference among
+// others from pool
+func (r renderer) askChoice(w io.Writer, pool []Sloka, s Sloka, rng *rand.Rand) (bool, error) {
+	fmt.Fprintf(w, "%s\n\n", r.questionText(s))
+	options := []Sloka{s}
+	for _, i := range rng.Perm(len(pool)) {
+		if len(options) == quizChoices {
+			break
+		}
+		if pool[i].ID != s.ID {
+			options = append(options, pool[i])
+		}
+	}
+	rng.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	for i, o := range options {
+		fmt.Fprintf(w, "  %c) %d.%d\n", 'a'+i, o.Chapter, o.Verse)
+	}
+	fmt.Fprintln(w)
+
+	for {
+		answer, err := ask("Answer: ")
+		if err != nil {
+			return false, err
+		}
+		answer = strings.ToLower(answer)
+		if len(answer) != 1 || answer[0] < 'a' || int(answer[0]-'a') >= len(options) {
+			fmt.Fprintf(w, "Pick a letter from a to %c.\n", 'a'+len(options)-1)
+			continue
+		}
+		if options[answer[0]-'a'].ID == s.ID {
+			fmt.Fprintln(w, "Correct!")
+			return true, nil
+		}
+		fmt.Fprintf(w, "It is %d.%d.\n", s.Chapter, s.Verse)
+		return false, nil
+	}
+}
+
+// askRecall shows the opening line of s, then the whole verse once the rest
+// has been recited, and asks whether it was remembered
+func (r renderer) askRecall(w io.Writer, s Sloka) (bool, error) {
+	if err := r.recite(w, s); err != nil {
+		return false, err
+	}
+	answer, err := ask("Did you remember it? [y/n] ")
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y"), nil
+}
+
+// recite shows the opening line of s, waits for the rest to be recited and
+// then shows the whole verse to check it against
+func (r renderer) recite(w io.Writer, s Sloka) error {
+	var sanskrit []string
+	for _, line := range strings.Split(s.Slok, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sanskrit = append(sanskrit, line)
+		}
+	}
+	roman := transliterationLines(r.transliteration(s))
+
+	// the opening line, after the speaker if the verse names one
+	shown := 1
+	if len(sanskrit) > 2 && strings.Contains(sanskrit[0], "उवाच") {
+		shown = 2
+	}
+	for _, line := range sanskrit[:min(shown, len(sanskrit))] {
+		fmt.Fprintln(w, r.highlighted(SanskritStyle, r.wrap(line)))
+	}
+	for _, line := range roman[:min(shown, len(roman))] {
+		fmt.Fprintln(w, r.highlighted(TransliterationStyle, r.wrap(line)))
+	}
+	fmt.Fprintln(w)
+	if _, err := ask("Recite the rest, then press Enter: "); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, line := range sanskrit {
+		fmt.Fprintln(w, r.highlighted(SanskritStyle, r.wrap(line)))
+	}
+	for _, line := range roman {
+		fmt.Fprintln(w, r.highlighted(TransliterationStyle, r.wrap(line)))
+	}
+	fmt.Fprintf(w, "%s(%d.%d)%s\n\n", Dim, s.Chapter, s.Verse, Reset)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// Options are the resolved settings for rendering verses as text
+type Options struct {
+	renderer
+	ChapterInfo bool   // print the chapter information before each chapter's verses
+	Cow         bool   // draw the verses in a speech bubble
+	Figure      string // figure below the speech bubble, a key of figures
+	Context     int    // verses of the same chapter shown dimmed around each verse
+	Compare     bool   // show the two verses side by side
+	Box         string // style of the frame drawn around the verses, "" for none
+	Center      int    // width of the screen to center the verses in, 0 for none
+}
+
+// render writes slokas to w exactly as they are printed on screen, looking
+// up chapter information in book
+func render(w io.Writer, book gita.Scripture, slokas []Sloka, opts Options) {
+	// context verses are shown once, and not at all when they are shown
+	// anyway, as within a range
+	shown := make(map[[2]int]bool, len(slokas))
+	for _, sloka := range slokas {
+		shown[[2]int{sloka.Chapter, sloka.Verse}] = true
+	}
+	context := func(out io.Writer, verses []Sloka) {
+		for _, s := range verses {
+			if key := [2]int{s.Chapter, s.Verse}; !shown[key] {
+				shown[key] = true
+				opts.dimmed(out, s)
+			}
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintln(&out)
+	if opts.Compare && len(slokas) == 2 {
+		opts.compare(&out, slokas[0], slokas[1])
+		slokas = nil
+	}
+	for i, sloka := range slokas {
+		// show chapter info if requested, once per run of verses
+		if opts.ChapterInfo && (i == 0 || slokas[i-1].Chapter != sloka.Chapter) {
+			if chapter, ok := book.Chapter(sloka.Chapter); ok {
+				opts.chapterInfo(&out, chapter)
+			}
+		}
+		before, after := contextVerses(book, sloka, opts.Context)
+		context(&out, before)
+		opts.sloka(&out, sloka)
+		context(&out, after)
+	}
+
+	rendered := out.String()
+	if opts.Cow {
+		rendered = "\n" + speechBubble(rendered, figures[opts.Figure]) + "\n"
+	}
+	if opts.Box != "" {
+		rendered = boxed(rendered, boxStyles[opts.Box])
+	}
+	if opts.Center > 0 {
+		rendered = centered(rendered, opts.Center)
+	}
+	io.WriteString(w, rendered)
+}
+
+// contextVerses returns up to n verses before and after s in its chapter
+func contextVerses(book gita.Scripture, s Sloka, n int) (before, after []Sloka) {
+	if n == 0 {
+		return nil, nil
+	}
+	verses := inOrder(book.Verses(s.Chapter))
+	i := slices.IndexFunc(verses, func(v Sloka) bool { return v.Verse == s.Verse })
+	if i < 0 {
+		return nil, nil
+	}
+	return verses[max(0, i-n):i], verses[i+1 : min(len(verses), i+1+n)]
+}
+
+// dimmed writes s as context of the verses being shown: without its own
+// styling and dim, so the shown verses stand out
+func (r renderer) dimmed(w io.Writer, s Sloka) {
+	var b strings.Builder
+	r.sloka(&b, s)
+	for _, line := range strings.SplitAfter(ansiEscape.ReplaceAllString(b.String(), ""), "\n") {
+		if text := strings.TrimSuffix(line, "\n"); strings.TrimSpace(text) != "" {
+			line = Dim + text + Reset + line[len(text):]
+		}
+		io.WriteString(w, line)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/render/NAME.golden, or with -update
+// writes it there
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "render", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to write it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	book := testBook(t)
+	s := testSloka(t, book, 2, 47)
+	base := testRenderer(book)
+	tty := func(opts Options) verseFormat { return ttyFormat{opts: opts, book: book, styled: true} }
+
+	tests := []struct {
+		name   string
+		format func(r renderer) verseFormat
+	}{
+		{"tty", func(r renderer) verseFormat { return tty(Options{renderer: r}) }},
+		{"tty-width-40", func(r renderer) verseFormat {
+			r.width = 40
+			return tty(Options{renderer: r})
+		}},
+		{"tty-balanced-50", func(r renderer) verseFormat {
+			r.wrapper, r.width = wrapBalanced, 50
+			return tty(Options{renderer: r})
+		}},
+		{"tty-justify-50", func(r renderer) verseFormat {
+			r.wrapper = func(text string, width int) string { return justify(wrapText(text, width), width) }
+			r.width = 50
+			return tty(Options{renderer: r})
+		}},
+		{"tty-highlight-cite", func(r renderer) verseFormat {
+			r.highlight, r.cite = true, CiteShort
+			return tty(Options{renderer: r})
+		}},
+		{"tty-box", func(r renderer) verseFormat {
+			r.width -= boxMargin
+			return tty(Options{renderer: r, Box: "rounded"})
+		}},
+		{"piped", func(r renderer) verseFormat {
+			return ttyFormat{opts: Options{renderer: r}, book: book}
+		}},
+		{"plain", func(r renderer) verseFormat {
+			return plainFormat{ttyFormat{opts: Options{renderer: r}, book: book, styled: true}}
+		}},
+		{"json", func(r renderer) verseFormat { return jsonFormat{r: r, book: book} }},
+		{"json-all-translations", func(r renderer) verseFormat {
+			return jsonFormat{r: r, book: book, allTranslations: true}
+		}},
+		{"yaml", func(r renderer) verseFormat { return jsonFormat{r: r, book: book, yaml: true} }},
+		{"markdown", func(r renderer) verseFormat { return markdownFormat{r: r, book: book} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := tt.format(base).writeVerses(&out, []Sloka{s}); err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tt.name, out.String())
+		})
+	}
+}
+
+func TestRenderLineWidth(t *testing.T) {
+	book := testBook(t)
+	s := testSloka(t, book, 2, 47)
+	for _, width := range []int{minWidth, 40, displayWidth, 100} {
+		r := testRenderer(book)
+		r.width = width
+		var out strings.Builder
+		render(&out, book, []Sloka{s}, Options{renderer: r})
+		// the verse lines are kept whole; the translation is wrapped
+		var translation string
+		for _, para := range strings.Split(out.String(), "\n\n") {
+			if strings.HasPrefix(para, "2.47 ") {
+				translation = para
+			}
+		}
+		if translation == "" {
+			t.Fatalf("width %d: no translation in\n%s", width, out.String())
+		}
+		for _, line := range strings.Split(translation, "\n") {
+			if w := visibleWidth(line); w > width {
+				t.Errorf("width %d: line %q is %d columns", width, line, w)
+			}
+		}
+	}
+}
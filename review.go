@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// card is the SM-2 schedule of one favorite verse
+type card struct {
+	Ease        float64 `json:"ease"`        // growth factor of the interval, at least minEase
+	Interval    int     `json:"interval"`    // days until the next review
+	Repetitions int     `json:"repetitions"` // reviews in a row graded 3 or better
+	Due         string  `json:"due"`         // date of the next review, e.g. "2024-02-14"
+}
+
+const (
+	initialEase = 2.5
+	minEase     = 1.3
+)
+
+// reviewsPath returns the file holding the review schedule
+func reviewsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "reviews.json"), nil
+}
+
+// loadReviews returns the review schedule keyed by sloka id. A missing file
+// means no verse has been reviewed yet.
+func loadReviews() (map[string]card, error) {
+	path, err := reviewsPath()
+	if err != nil {
+		return nil, err
+	}
+	cards := make(map[string]card)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cards, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cards); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cards, nil
+}
+
+// saveReviews replaces the review schedule with cards
+func saveReviews(cards map[string]card) error {
+	path, err := reviewsPath()
+	if err != nil {
+		return err
+	}
+	return writeState(path, cards)
+}
+
+// schedule returns c after a review on today graded 1 (forgotten) to 5
+// (perfect recall), following SM-2: a grade below 3 starts the verse over,
+// otherwise the interval grows from 1 to 6 days and then by the ease, which
+// itself moves with the grade.
+func schedule(c card, grade int, today time.Time) card {
+	if c.Ease == 0 {
+		c.Ease = initialEase
+	}
+	if grade < 3 {
+		c.Repetitions, c.Interval = 0, 1
+	} else {
+		switch c.Repetitions {
+		case 0:
+			c.Interval = 1
+		case 1:
+			c.Interval = 6
+		default:
+			c.Interval = int(math.Round(float64(c.Interval) * c.Ease))
+		}
+		c.Repetitions++
+	}
+	miss := float64(5 - grade)
+	c.Ease = max(minEase, c.Ease+0.1-miss*(0.08+miss*0.02))
+	c.Due = dayKey(today.AddDate(0, 0, c.Interval))
+	return c
+}
+
+// dueSlokas returns the favorites due for review on today: overdue verses
+// first, the longest overdue leading, then those never reviewed in the
+// order they were added
+func dueSlokas(book gita.Scripture, ids []string, cards map[string]card, today time.Time) []Sloka {
+	var due, fresh []Sloka
+	for _, s := range favoriteSlokas(book, ids) {
+		c, ok := cards[s.ID]
+		switch {
+		case !ok:
+			fresh = append(fresh, s)
+		case c.Due <= dayKey(today):
+			due = append(due, s)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return cards[due[i].ID].Due < cards[due[j].ID].Due })
+	return append(due, fresh...)
+}
+
+// nextDue returns the earliest due date among the scheduled favorites, or
+// "" when none is scheduled
+func nextDue(ids []string, cards map[string]card) string {
+	next := ""
+	for _, id := range ids {
+		if c, ok := cards[id]; ok && (next == "" || c.Due < next) {
+			next = c.Due
+		}
+	}
+	return next
+}
+
+// review goes through slokas: each is recited from its opening line and
+// graded, and the schedule is saved after every grade so that stopping
+// early with q keeps the progress made
+func review(w io.Writer, slokas []Sloka, cards map[string]card, r renderer, today time.Time) (int, error) {
+	reviewed := 0
+	for i, s := range slokas {
+		fmt.Fprintf(w, "\n%s\n\n", r.header(fmt.Sprintf("Review %d of %d", i+1, len(slokas))))
+		grade, err := r.gradeRecall(w, s)
+		if errors.Is(err, io.EOF) || errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			return reviewed, err
+		}
+		cards[s.ID] = schedule(cards[s.ID], grade, today)
+		if err := saveReviews(cards); err != nil {
+			return reviewed, err
+		}
+		reviewed++
+		fmt.Fprintf(w, "Next review on %s.\n", cards[s.ID].Due)
+	}
+	return reviewed, nil
+}
+
+// gradeRecall recites s and asks for a grade from 1 to 5
+func (r renderer) gradeRecall(w io.Writer, s Sloka) (int, error) {
+	if err := r.recite(w, s); err != nil {
+		return 0, err
+	}
+	for {
+		answer, err := ask("Grade your recall, 1 (forgot) to 5 (perfect): ")
+		if err != nil {
+			return 0, err
+		}
+		if grade, err := strconv.Atoi(answer); err == nil && grade >= 1 && grade <= 5 {
+			return grade, nil
+		}
+		fmt.Fprintln(w, "Enter a number from 1 to 5.")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// rotation is the persisted state of -rotate: a shuffled order of sloka
+// indices and the position of the next one to show
+type rotation struct {
+	Order  []int `json:"order"`
+	Cursor int   `json:"cursor"`
+}
+
+// stateDir returns the directory for persistent state, honoring XDG_STATE_HOME
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gitasay"), nil
+	}
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitasay"), nil
+}
+
+// nextRotation returns the next sloka index of the persisted rotation over n
+// slokas, reshuffling with rng once every sloka has been shown
+func nextRotation(n int, rng *rand.Rand) (int, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return 0, err
+	}
+	path := filepath.Join(dir, "rotation.json")
+
+	var state rotation
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if err == nil && json.Unmarshal(data, &state) != nil {
+		state = rotation{}
+	}
+
+	// start a new permutation when exhausted or when the dataset size changed
+	if len(state.Order) != n || state.Cursor < 0 || state.Cursor >= n {
+		state = rotation{Order: rng.Perm(n)}
+	}
+	index := state.Order[state.Cursor]
+	state.Cursor++
+
+	if err := writeState(path, state); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// writeState atomically writes v as JSON to path, creating its directory
+func writeState(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// history is the persisted state of -no-repeat: the ids of slokas already shown
+type history struct {
+	Shown []string `json:"shown"`
+}
+
+// drawUnseen picks n distinct slokas of pool that -no-repeat has not shown
+// yet and records them. Once fewer than n remain unseen, the pool's slokas
+// are forgotten so that the cycle starts over.
+func drawUnseen(pool []Sloka, n int, rng *rand.Rand) ([]Sloka, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "history.json")
+
+	var state history
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil && json.Unmarshal(data, &state) != nil {
+		state = history{}
+	}
+	shown := make(map[string]bool, len(state.Shown))
+	for _, id := range state.Shown {
+		shown[id] = true
+	}
+
+	var unseen []Sloka
+	for _, s := range pool {
+		if !shown[s.ID] {
+			unseen = append(unseen, s)
+		}
+	}
+	if len(unseen) < n {
+		// every sloka of the pool has been shown: reset it
+		for _, s := range pool {
+			delete(shown, s.ID)
+		}
+		unseen = pool
+	}
+
+	picks := make([]Sloka, 0, n)
+	for _, i := range rng.Perm(len(unseen))[:n] {
+		picks = append(picks, unseen[i])
+		shown[unseen[i].ID] = true
+	}
+
+	state.Shown = state.Shown[:0]
+	for id := range shown {
+		state.Shown = append(state.Shown, id)
+	}
+	sort.Strings(state.Shown)
+	if err := writeState(path, state); err != nil {
+		return nil, err
+	}
+	return picks, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Search fields accepted by -search-in, besides the source keys, which
+// search the translation of that source
+const (
+	FieldTranslation     = "translation"
+	FieldTranslations    = "translations"
+	FieldSanskrit        = "sanskrit"
+	FieldTransliteration = "transliteration"
+)
+
+// searchMatch is a sloka matching a search along with the text that matched
+type searchMatch struct {
+	sloka Sloka
+	text  string
+}
+
+// parseSearchFields parses a comma-separated -search-in value
+func parseSearchFields(value string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(value, ",") {
+		switch f = strings.TrimSpace(f); f {
+		case FieldTranslation, FieldSanskrit, FieldTransliteration:
+			fields = append(fields, f)
+		case FieldTranslations:
+			// every source, in -list-sources order
+			for _, t := range translators {
+				fields = append(fields, t.Key)
+			}
+		default:
+			if !isSource(f) {
+				return nil, fmt.Errorf("unknown search field %q", f)
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// searchSlokas returns the slokas for which one of fields matches pattern,
+// searching the active translation for "translation" and the translation
+// of that source for a source key
+func searchSlokas(slokas []Sloka, r renderer, pattern *regexp.Regexp, fields []string) []searchMatch {
+	var matches []searchMatch
+	for _, s := range slokas {
+		for _, field := range fields {
+			var text string
+			switch field {
+			case FieldTranslation:
+				text, _ = r.resolve(s).translation(s)
+				text = cleanTranslation(text)
+			case FieldSanskrit:
+				text = strings.Join(strings.Fields(s.Slok), " ")
+			case FieldTransliteration:
+				text = strings.Join(strings.Fields(s.Transliteration), " ")
+			default:
+				c := r
+				c.source = field
+				text, _ = c.translation(s)
+				text = cleanTranslation(text)
+			}
+			if pattern.MatchString(text) {
+				matches = append(matches, searchMatch{sloka: s, text: text})
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// isSource reports whether key names a translation source
+func isSource(key string) bool {
+	for _, t := range translators {
+		if t.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceKeys returns the keys of the translation sources
+func sourceKeys() []string {
+	keys := make([]string, 0, len(translators))
+	for _, t := range translators {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
+
+// printSearchResults writes one line per match: the bold reference followed
+// by a snippet of the matching text with the term highlighted
+func printSearchResults(w io.Writer, matches []searchMatch, r renderer, pattern *regexp.Regexp) {
+	for _, m := range matches {
+		ref := fmt.Sprintf("%d.%d", m.sloka.Chapter, m.sloka.Verse)
+
+		// measure on plain text, the escape codes are added afterwards
+		width := r.width - len(r.text.Abbrev) - 1 - len(ref) - 2
+		snippet := snippetAround(m.text, pattern, width)
+		snippet = pattern.ReplaceAllStringFunc(snippet, func(m string) string {
+			return Reverse + m + Reset
+		})
+		fmt.Fprintf(w, "%s%s %s%s  %s\n", Bold, r.text.Abbrev, ref, Reset, snippet)
+	}
+}
+
+// snippetAround returns at most width runes of text centred on the first
+// match of pattern, marking cut ends with an ellipsis
+func snippetAround(text string, pattern *regexp.Regexp, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+
+	loc := pattern.FindStringIndex(text)
+	start := 0
+	if loc != nil {
+		matchStart := utf8.RuneCountInString(text[:loc[0]])
+		matchLen := utf8.RuneCountInString(text[loc[0]:loc[1]])
+		start = matchStart - (width-matchLen)/2
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + width
+	if end > len(runes) {
+		end = len(runes)
+		start = end - width
+	}
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// selection strategies accepted by -select
+const (
+	SelectRandom   = "random"
+	SelectShortest = "shortest"
+	SelectLongest  = "longest"
+	SelectFirst    = "first"
+	SelectLast     = "last"
+	SelectPopular  = "popular"
+)
+
+// selectStrategies lists the -select strategies, the default first
+var selectStrategies = []string{SelectRandom, SelectShortest, SelectLongest, SelectFirst, SelectLast, SelectPopular}
+
+// selectSloka picks a sloka from the non-empty pool using strategy. Lengths
+// are the rune counts of the active translation; verses without one are
+// skipped by shortest and longest, and ties go to the earliest verse in
+// chapter and verse order. popular draws at random with the curated weight
+// of each verse, so the well-known verses come up far more often.
+func selectSloka(pool []Sloka, strategy string, r renderer, rng *rand.Rand) Sloka {
+	switch strategy {
+	case SelectRandom:
+		return pool[rng.Intn(len(pool))]
+	case SelectPopular:
+		total := 0
+		for _, s := range pool {
+			total += gita.Weight(s.ID)
+		}
+		n := rng.Intn(total)
+		for _, s := range pool {
+			if n -= gita.Weight(s.ID); n < 0 {
+				return s
+			}
+		}
+	}
+
+	ordered := inOrder(pool)
+	switch strategy {
+	case SelectFirst:
+		return ordered[0]
+	case SelectLast:
+		return ordered[len(ordered)-1]
+	}
+
+	best, bestLen := ordered[0], -1
+	for _, s := range ordered {
+		text, _ := r.resolve(s).translation(s)
+		length := utf8.RuneCountInString(cleanTranslation(text))
+		if length == 0 {
+			continue
+		}
+		if bestLen < 0 || (strategy == SelectShortest && length < bestLen) || (strategy == SelectLongest && length > bestLen) {
+			best, bestLen = s, length
+		}
+	}
+	return best
+}
+
+// parseChapters parses a -chapters list such as "2,12,18" or "2-6,12" into
+// the sorted chapter numbers, each of which must be between 1 and count
+func parseChapters(value string, count int) ([]int, error) {
+	seen := make(map[int]bool)
+	var chapters []int
+	for _, part := range strings.Split(value, ",") {
+		first, last, err := parseRange(part)
+		if err != nil {
+			return nil, err
+		}
+		if first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("%q: valid chapters are 1-%d", strings.TrimSpace(part), count)
+		}
+		for c := first; c <= last; c++ {
+			if !seen[c] {
+				seen[c] = true
+				chapters = append(chapters, c)
+			}
+		}
+	}
+	sort.Ints(chapters)
+	return chapters, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// server answers the HTTP API of -serve from a loaded dataset
+type server struct {
+	book gita.Scripture
+	r    renderer // display settings, overridable per request
+
+	mu  sync.Mutex // guards rng, which is not safe for concurrent use
+	rng *rand.Rand
+	loc *time.Location // time zone of the Gemini verse of the day
+}
+
+// ChapterJSON is a chapter with its verses in order, as served by /chapter/{n}
+type ChapterJSON struct {
+	Chapter Chapter     `json:"chapter"`
+	Verses  []VerseJSON `json:"verses"`
+}
+
+// handler returns the routes of the API
+func (sv *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /random", sv.random)
+	mux.HandleFunc("GET /verse/{chapter}/{verse}", sv.verse)
+	mux.HandleFunc("GET /chapter/{n}", sv.chapter)
+	mux.HandleFunc("GET /search", sv.search)
+	mux.HandleFunc("GET /chapters", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, sv.book.Data().Chapters)
+	})
+	return mux
+}
+
+// renderer returns the display settings for req: the translation query
+// parameter picks the source
+func (sv *server) renderer(req *http.Request) (renderer, error) {
+	r := sv.r
+	if source := req.URL.Query().Get("translation"); source != "" {
+		if !isSource(source) {
+			return r, fmt.Errorf("invalid translation source %q", source)
+		}
+		r.source, r.autoSource = source, ""
+	}
+	return r, nil
+}
+
+// random serves a random verse, from the chapter query parameter if given
+func (sv *server) random(w http.ResponseWriter, req *http.Request) {
+	r, err := sv.renderer(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pool := sv.book.Data().Slokas
+	if c := req.URL.Query().Get("chapter"); c != "" {
+		n, err := strconv.Atoi(c)
+		if err != nil || len(sv.book.Verses(n)) == 0 {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("chapter %s not found", c))
+			return
+		}
+		pool = sv.book.Verses(n)
+	}
+	sv.mu.Lock()
+	s := pool[sv.rng.Intn(len(pool))]
+	sv.mu.Unlock()
+	sv.writeVerses(w, req, r, s)
+}
+
+// verse serves the verse at /verse/{chapter}/{verse}
+func (sv *server) verse(w http.ResponseWriter, req *http.Request) {
+	r, err := sv.renderer(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	chapter, err1 := strconv.Atoi(req.PathValue("chapter"))
+	verse, err2 := strconv.Atoi(req.PathValue("verse"))
+	s, ok := sv.book.Get(chapter, verse)
+	if err1 != nil || err2 != nil || !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chapter %s, verse %s not found", req.PathValue("chapter"), req.PathValue("verse")))
+		return
+	}
+	sv.writeVerses(w, req, r, s)
+}
+
+// chapter serves the information and verses of /chapter/{n}
+func (sv *server) chapter(w http.ResponseWriter, req *http.Request) {
+	r, err := sv.renderer(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	n, err := strconv.Atoi(req.PathValue("n"))
+	info, ok := sv.book.Chapter(n)
+	if err != nil || !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("chapter %s not found", req.PathValue("n")))
+		return
+	}
+	verses := inOrder(sv.book.Verses(n))
+	if wantsText(req) {
+		sv.writeVerses(w, req, r, verses...)
+		return
+	}
+	out := ChapterJSON{Chapter: info, Verses: make([]VerseJSON, 0, len(verses))}
+	for _, s := range verses {
+		out.Verses = append(out.Verses, r.verseJSON(s, false))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// search serves the verses matching the q query parameter, searched in the
+// fields of the in parameter and cut to limit results
+func (sv *server) search(w http.ResponseWriter, req *http.Request) {
+	r, err := sv.renderer(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := req.URL.Query()
+	term := strings.TrimSpace(query.Get("q"))
+	if term == "" {
+		writeError(w, http.StatusBadRequest, "missing search term q")
+		return
+	}
+	in := query.Get("in")
+	if in == "" {
+		in = FieldTranslation
+	}
+	fields, err := parseSearchFields(in)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 0
+	if l := query.Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", l))
+			return
+		}
+	}
+
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+	matches := searchSlokas(inOrder(sv.book.Data().Slokas), r, pattern, fields)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	if wantsText(req) {
+		var b bytes.Buffer
+		printSearchResults(&b, matches, r, pattern)
+		writeText(w, http.StatusOK, b.String())
+		return
+	}
+	verses := make([]VerseJSON, 0, len(matches))
+	for _, m := range matches {
+		verses = append(verses, r.verseJSON(m.sloka, false))
+	}
+	writeJSON(w, http.StatusOK, verses)
+}
+
+// writeVerses writes slokas as JSON, a single object for one sloka, or as
+// the usual text when the client asks for it
+func (sv *server) writeVerses(w http.ResponseWriter, req *http.Request, r renderer, slokas ...Sloka) {
+	if wantsText(req) {
+		var b bytes.Buffer
+		for _, s := range slokas {
+			r.sloka(&b, s)
+		}
+		writeText(w, http.StatusOK, b.String())
+		return
+	}
+	if len(slokas) == 1 {
+		writeJSON(w, http.StatusOK, r.verseJSON(slokas[0], false))
+		return
+	}
+	verses := make([]VerseJSON, 0, len(slokas))
+	for _, s := range slokas {
+		verses = append(verses, r.verseJSON(s, false))
+	}
+	writeJSON(w, http.StatusOK, verses)
+}
+
+// wantsText reports whether req asks for plain text, with format=text or by
+// preferring text/plain over JSON as curl users can with -H
+func wantsText(req *http.Request) bool {
+	switch req.URL.Query().Get("format") {
+	case "text":
+		return true
+	case "json":
+		return false
+	}
+	accept := req.Header.Get("Accept")
+	return strings.HasPrefix(accept, "text/plain")
+}
+
+// writeJSON writes v as indented JSON with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeText writes text as plain text with the given status
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, text)
+}
+
+// writeError writes msg as an ErrorJSON with the given status
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorJSON{Error: msg})
+}
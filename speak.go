@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// speakTimeout bounds the request to a -speak-url service
+const speakTimeout = 60 * time.Second
+
+// speakScript reads the text in GITASAY_TEXT aloud with SAPI, in the voice
+// and at the rate (-10 to 10) in GITASAY_VOICE and GITASAY_RATE
+const speakScript = `Add-Type -AssemblyName System.Speech
+$s = New-Object System.Speech.Synthesis.SpeechSynthesizer
+if ($env:GITASAY_VOICE) { $s.SelectVoice($env:GITASAY_VOICE) }
+if ($env:GITASAY_RATE) { $s.Rate = [int]$env:GITASAY_RATE }
+$s.Speak($env:GITASAY_TEXT)`
+
+// speaker is a text-to-speech command and how it takes the voice and the
+// rate in words per minute; the text is written to its stdin
+type speaker struct {
+	args  []string
+	voice func(name string) []string
+	rate  func(wpm int) []string
+}
+
+// speakers lists the text-to-speech tools to try on each platform, in order
+// of preference
+var speakers = map[string][]speaker{
+	"darwin": {{
+		args:  []string{"say", "-f", "-"},
+		voice: func(name string) []string { return []string{"-v", name} },
+		rate:  func(wpm int) []string { return []string{"-r", strconv.Itoa(wpm)} },
+	}},
+	"windows": {{
+		args:  []string{"powershell", "-NoProfile", "-Command", speakScript},
+		voice: func(string) []string { return nil },
+		rate:  func(int) []string { return nil },
+	}},
+	"linux": {
+		{
+			args:  []string{"espeak-ng", "--stdin"},
+			voice: func(name string) []string { return []string{"-v", name} },
+			rate:  func(wpm int) []string { return []string{"-s", strconv.Itoa(wpm)} },
+		},
+		{
+			args:  []string{"espeak", "--stdin"},
+			voice: func(name string) []string { return []string{"-v", name} },
+			rate:  func(wpm int) []string { return []string{"-s", strconv.Itoa(wpm)} },
+		},
+	},
+}
+
+// errNoSpeaker is returned when no text-to-speech tool is installed
+var errNoSpeaker = errors.New("no text-to-speech tool found")
+
+// spokenText returns what -speak reads for s: the citation, then the
+// translation without the verse number it may start with
+func (r renderer) spokenText(s Sloka) string {
+	r = r.resolve(s)
+	text, author := r.translation(s)
+	spoken := fmt.Sprintf("%s, chapter %d, verse %d.", r.text.Title, s.Chapter, s.Verse)
+	if text = cleanTranslation(text); text == "" {
+		return spoken + " No translation is available for this verse."
+	}
+	return fmt.Sprintf("%s %s Translated by %s.", spoken, text, author)
+}
+
+// speak reads text aloud in voice at wpm words per minute, either left at 0
+// or "" for the default, with the first text-to-speech tool available
+func speak(text, voice string, wpm int) error {
+	for _, sp := range speakers[runtime.GOOS] {
+		if _, err := exec.LookPath(sp.args[0]); err != nil {
+			continue
+		}
+		args := sp.args[1:]
+		if voice != "" {
+			args = append(sp.voice(voice), args...)
+		}
+		if wpm > 0 {
+			args = append(sp.rate(wpm), args...)
+		}
+		cmd := exec.Command(sp.args[0], args...)
+		cmd.Stdin = strings.NewReader(text)
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), "GITASAY_TEXT="+text, "GITASAY_VOICE="+voice)
+		if wpm > 0 {
+			// SAPI rates run from -10 to 10 around about 180 words per minute
+			cmd.Env = append(cmd.Env, "GITASAY_RATE="+strconv.Itoa(max(-10, min(10, (wpm-180)/20))))
+		}
+		return cmd.Run()
+	}
+	return errNoSpeaker
+}
+
+// speakRemote posts text to the speech service at endpoint as JSON with the
+// voice and rate, authorized with token when set, and plays the audio it
+// returns
+func speakRemote(endpoint, token, text, voice string, wpm int) error {
+	body, err := json.Marshal(map[string]any{"text": text, "voice": voice, "rate": wpm})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := (&http.Client{Timeout: speakTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", endpoint, resp.Status)
+	}
+
+	ext := ".mp3"
+	switch resp.Header.Get("Content-Type") {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		ext = ".wav"
+	case "audio/ogg", "audio/opus":
+		ext = ".ogg"
+	}
+	tmp, err := os.CreateTemp("", "gitasay-speech-*"+ext)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return playAudio(tmp.Name())
+}
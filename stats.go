@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// LengthStats summarizes the translation lengths, in runes, of one source
+type LengthStats struct {
+	Source string  `json:"source"`
+	Count  int     `json:"count"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+}
+
+// translationStats computes length statistics per translation source over
+// all non-empty translations
+func translationStats(slokas []Sloka, r renderer) []LengthStats {
+	var stats []LengthStats
+	for _, t := range translators {
+		r.source = t.Key
+		var lengths []int
+		for _, s := range slokas {
+			text, _ := r.translation(s)
+			if text = cleanTranslation(text); text != "" {
+				lengths = append(lengths, utf8.RuneCountInString(text))
+			}
+		}
+
+		st := LengthStats{Source: t.Key, Count: len(lengths)}
+		if len(lengths) > 0 {
+			sort.Ints(lengths)
+			total := 0
+			for _, l := range lengths {
+				total += l
+			}
+			st.Min = lengths[0]
+			st.Max = lengths[len(lengths)-1]
+			st.Mean = float64(total) / float64(len(lengths))
+			mid := len(lengths) / 2
+			if len(lengths)%2 == 0 {
+				st.Median = float64(lengths[mid-1]+lengths[mid]) / 2
+			} else {
+				st.Median = float64(lengths[mid])
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+// printLengthStats writes the length statistics as an aligned table
+func printLengthStats(w io.Writer, stats []LengthStats) {
+	fmt.Fprintf(w, "%-8s %6s %6s %6s %8s %8s\n", "SOURCE", "COUNT", "MIN", "MAX", "MEAN", "MEDIAN")
+	for _, st := range stats {
+		fmt.Fprintf(w, "%-8s %6d %6d %6d %8.1f %8.1f\n", st.Source, st.Count, st.Min, st.Max, st.Mean, st.Median)
+	}
+}
+
+// ChapterCount compares the slokas present for a chapter with the count the
+// chapter metadata declares
+type ChapterCount struct {
+	Chapter  int  `json:"chapter"`
+	Declared int  `json:"declared"`
+	Actual   int  `json:"actual"`
+	Mismatch bool `json:"mismatch"`
+}
+
+// SourceCoverage counts the slokas with non-empty text for one source
+type SourceCoverage struct {
+	Source  string `json:"source"`
+	Verses  int    `json:"verses"`
+	Missing int    `json:"missing"`
+}
+
+// DatasetStats summarizes how complete the dataset is
+type DatasetStats struct {
+	Chapters int              `json:"chapters"`
+	Slokas   int              `json:"slokas"`
+	Counts   []ChapterCount   `json:"verses_per_chapter"`
+	Coverage []SourceCoverage `json:"coverage"`
+}
+
+// datasetStats counts the chapters and slokas of data, the slokas per
+// chapter against the declared VersesCount and each source's coverage
+func datasetStats(book gita.Scripture) DatasetStats {
+	st := DatasetStats{Chapters: len(book.Data().Chapters), Slokas: len(book.Data().Slokas)}
+	for _, chapter := range book.Data().Chapters {
+		actual := len(book.Verses(chapter.ChapterNumber))
+		st.Counts = append(st.Counts, ChapterCount{
+			Chapter:  chapter.ChapterNumber,
+			Declared: chapter.VersesCount,
+			Actual:   actual,
+			Mismatch: actual != chapter.VersesCount,
+		})
+	}
+	for _, t := range translators {
+		c := SourceCoverage{Source: t.Key}
+		for _, s := range book.Data().Slokas {
+			if _, _, ok := resolveTranslation(s, t.Key); ok {
+				c.Verses++
+			}
+		}
+		c.Missing = st.Slokas - c.Verses
+		st.Coverage = append(st.Coverage, c)
+	}
+	return st
+}
+
+// printDatasetStats writes the dataset summary as aligned tables, marking
+// chapters whose sloka count differs from the declared one
+func printDatasetStats(w io.Writer, st DatasetStats) {
+	fmt.Fprintf(w, "Chapters: %d\nSlokas:   %d\n\n", st.Chapters, st.Slokas)
+
+	fmt.Fprintf(w, "%-8s %8s %8s\n", "CHAPTER", "DECLARED", "ACTUAL")
+	for _, c := range st.Counts {
+		mark := ""
+		if c.Mismatch {
+			mark = "  mismatch"
+		}
+		fmt.Fprintf(w, "%-8d %8d %8d%s\n", c.Chapter, c.Declared, c.Actual, mark)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%-8s %8s %8s %9s\n", "SOURCE", "VERSES", "MISSING", "COVERAGE")
+	for _, c := range st.Coverage {
+		coverage := 0.0
+		if st.Slokas > 0 {
+			coverage = 100 * float64(c.Verses) / float64(st.Slokas)
+		}
+		fmt.Fprintf(w, "%-8s %8d %8d %8.1f%%\n", c.Source, c.Verses, c.Missing, coverage)
+	}
+}
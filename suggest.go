@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// levenshtein returns the number of single rune insertions, deletions and
+// substitutions that turn a into b
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range s {
+		cur := make([]int, len(t)+1)
+		cur[0] = i + 1
+		for j := range t {
+			cost := 1
+			if s[i] == t[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(t)]
+}
+
+// closest returns the option nearest to word ignoring case, or "" when
+// none is within a third of its length, so that typos are caught but
+// unrelated words are not matched
+func closest(word string, options []string) string {
+	word = strings.ToLower(word)
+	best, bestDistance := "", max(1, len([]rune(word))/3)+1
+	for _, option := range options {
+		if d := levenshtein(word, strings.ToLower(option)); d < bestDistance {
+			best, bestDistance = option, d
+		}
+	}
+	return best
+}
+
+// flagError handles an error from parsing fs, as flag.ExitOnError would,
+// adding the nearest flag to one that is not defined
+func flagError(fs *flag.FlagSet, err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "flag provided but not defined: -"); ok {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+		if s := closest(name, names); s != "" {
+			fmt.Fprintf(fs.Output(), "\nDid you mean -%s?\n", s)
+		}
+	}
+	os.Exit(exitUsage)
+}
+
+// chapterValue is the -c flag: a chapter number, or a name that is looked
+// up once the chapters are loaded
+type chapterValue struct {
+	number int
+	name   string
+}
+
+// String implements flag.Value
+func (c *chapterValue) String() string {
+	if c.name != "" {
+		return c.name
+	}
+	return strconv.Itoa(c.number)
+}
+
+// Set implements flag.Value
+func (c *chapterValue) Set(value string) error {
+	if n, err := strconv.Atoi(value); err == nil {
+		c.number, c.name = n, ""
+		return nil
+	}
+	if strings.TrimSpace(value) == "" {
+		return errors.New("empty chapter")
+	}
+	c.number, c.name = 0, value
+	return nil
+}
+
+// chapterKey returns name as findChapter compares it: romanized names
+// folded like concordance keys, Devanagari ones without spaces and dashes
+func chapterKey(name string) string {
+	if isLatin(name) {
+		return foldIAST(name)
+	}
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// findChapter returns the chapters named name, matched ignoring case, spaces
+// and diacritics against their Sanskrit name, its translation and
+// transliteration, and their meaning, as in "karma yoga", "Karm Yog",
+// "कर्मयोग" or "selfless service". An exact name wins, then the one chapter
+// whose names contain name, then the chapter with the nearest name; several
+// chapters are returned when name is part of the names of each.
+func findChapter(chapters []Chapter, name string) []Chapter {
+	key := chapterKey(name)
+	if key == "" {
+		return nil
+	}
+	var names []string
+	byName := make(map[string]Chapter)
+	var partial []Chapter
+	for _, c := range chapters {
+		contains := false
+		for _, n := range []string{c.Name, c.Translation, c.Transliteration, c.Meaning.En, c.Meaning.Hi} {
+			k := chapterKey(n)
+			if k == "" {
+				continue
+			}
+			if k == key {
+				return []Chapter{c}
+			}
+			names = append(names, k)
+			byName[k] = c
+			contains = contains || strings.Contains(k, key)
+		}
+		if contains {
+			partial = append(partial, c)
+		}
+	}
+	if len(partial) == 1 {
+		return partial
+	}
+	if c, ok := byName[closest(key, names)]; ok {
+		return []Chapter{c}
+	}
+	return partial
+}
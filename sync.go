@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// backends accepted by -sync-backend
+const (
+	SyncGist   = "gist"
+	SyncWebDAV = "webdav"
+)
+
+// syncBackends lists the -sync-backend values
+var syncBackends = []string{SyncGist, SyncWebDAV}
+
+const (
+	syncTimeout = 30 * time.Second // for each request to the backend
+	syncFile    = "gitasay.json"   // name of the file in the gist
+)
+
+// syncState is what -fav-sync keeps in the backend: the favorites, their
+// review schedule and when they last changed
+type syncState struct {
+	Updated   time.Time       `json:"updated"`
+	Favorites []string        `json:"favorites"`
+	Reviews   map[string]card `json:"reviews"`
+}
+
+// syncBackend stores the synced state remotely
+type syncBackend interface {
+	// pull returns the stored state, nil when nothing is stored yet
+	pull() (*syncState, error)
+	// push replaces the stored state
+	push(state syncState) error
+	// String names the store for messages
+	String() string
+}
+
+// newSyncBackend returns the backend of kind at location: a gist id, empty
+// to create a gist on the first push, or the URL of a file on a WebDAV server
+func newSyncBackend(kind, location, token string) (syncBackend, error) {
+	client := &http.Client{Timeout: syncTimeout}
+	switch kind {
+	case SyncGist:
+		if token == "" {
+			return nil, errors.New("the gist backend needs a GitHub token with the gist scope, set with -sync-token or GITASAY_SYNC_TOKEN")
+		}
+		api := strings.TrimSuffix(firstNonEmpty(os.Getenv("GITHUB_API_URL"), "https://api.github.com"), "/")
+		return &gistBackend{client: client, api: api, id: location, token: token}, nil
+	case SyncWebDAV:
+		if location == "" {
+			return nil, errors.New("the webdav backend needs the URL of the file to sync, set with -sync-url or GITASAY_SYNC_URL")
+		}
+		return &webdavBackend{client: client, url: location, token: token}, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", kind)
+}
+
+// localSyncState returns the favorites and review schedule, updated when
+// either file last changed, the zero time when neither exists
+func localSyncState() (syncState, error) {
+	ids, err := loadFavorites()
+	if err != nil {
+		return syncState{}, err
+	}
+	cards, err := loadReviews()
+	if err != nil {
+		return syncState{}, err
+	}
+	state := syncState{Favorites: ids, Reviews: cards}
+	if state.Favorites == nil {
+		state.Favorites = []string{}
+	}
+	for _, path := range []func() (string, error){favoritesPath, reviewsPath} {
+		p, err := path()
+		if err != nil {
+			return syncState{}, err
+		}
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(state.Updated) {
+			state.Updated = info.ModTime()
+		}
+	}
+	state.Updated = state.Updated.UTC().Truncate(time.Second)
+	return state, nil
+}
+
+// saveSyncState replaces the favorites and review schedule with state,
+// dating both files to when it was updated so the next sync sees no change
+func saveSyncState(state syncState) error {
+	if err := saveFavorites(state.Favorites); err != nil {
+		return err
+	}
+	if state.Reviews == nil {
+		state.Reviews = map[string]card{}
+	}
+	if err := saveReviews(state.Reviews); err != nil {
+		return err
+	}
+	for _, path := range []func() (string, error){favoritesPath, reviewsPath} {
+		p, err := path()
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(p, state.Updated, state.Updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncFavorites pushes the local state to b when it changed last, or when
+// force is set, and otherwise pulls the backend's when that changed last,
+// reporting which happened
+func syncFavorites(b syncBackend, force bool) (string, error) {
+	local, err := localSyncState()
+	if err != nil {
+		return "", err
+	}
+	remote, err := b.pull()
+	if err != nil {
+		return "", err
+	}
+	if force {
+		// date the local state now, so it also wins on the other machines
+		local.Updated = time.Now().UTC().Truncate(time.Second)
+		if err := saveSyncState(local); err != nil {
+			return "", err
+		}
+	}
+	switch {
+	case force || remote == nil || local.Updated.After(remote.Updated):
+		if err := b.push(local); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Pushed %d favorites to %s.", len(local.Favorites), b), nil
+	case remote.Updated.After(local.Updated) || !slices.Equal(remote.Favorites, local.Favorites) || !maps.Equal(remote.Reviews, local.Reviews):
+		// changes within the same second go to the backend's side
+		if err := saveSyncState(*remote); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Pulled %d favorites from %s, saved there on %s.", len(remote.Favorites), b,
+			remote.Updated.Local().Format("2006-01-02 15:04")), nil
+	}
+	return fmt.Sprintf("The favorites are in sync with %s.", b), nil
+}
+
+// gistBackend keeps the state in a secret GitHub gist
+type gistBackend struct {
+	client    *http.Client
+	api       string
+	id, token string
+}
+
+func (g *gistBackend) String() string {
+	if g.id == "" {
+		return "a new gist"
+	}
+	return "gist " + g.id
+}
+
+// do sends a request to the gists API with body as JSON, decoding the
+// reply into reply
+func (g *gistBackend) do(method, path string, body, reply any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, g.api+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("%s: %s", resp.Status, e.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+// gist is the part of the gists API's gist object the backend uses
+type gist struct {
+	ID    string `json:"id"`
+	Files map[string]struct {
+		Content string `json:"content"`
+	} `json:"files"`
+}
+
+func (g *gistBackend) pull() (*syncState, error) {
+	if g.id == "" {
+		return nil, nil
+	}
+	var reply gist
+	if err := g.do(http.MethodGet, "/gists/"+g.id, nil, &reply); err != nil {
+		return nil, err
+	}
+	file, ok := reply.Files[syncFile]
+	if !ok {
+		return nil, nil
+	}
+	var state syncState
+	if err := json.Unmarshal([]byte(file.Content), &state); err != nil {
+		return nil, fmt.Errorf("gist %s: %v", g.id, err)
+	}
+	return &state, nil
+}
+
+func (g *gistBackend) push(state syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	body := map[string]any{
+		"description": "gitasay favorites",
+		"files":       map[string]any{syncFile: map[string]string{"content": string(data)}},
+	}
+	if g.id != "" {
+		return g.do(http.MethodPatch, "/gists/"+g.id, body, &gist{})
+	}
+	body["public"] = false
+	var reply gist
+	if err := g.do(http.MethodPost, "/gists", body, &reply); err != nil {
+		return err
+	}
+	g.id = reply.ID
+	return nil
+}
+
+// webdavBackend keeps the state in a file on a WebDAV server
+type webdavBackend struct {
+	client *http.Client
+	url    string
+	token  string // USER:PASSWORD for basic authentication, else a bearer token
+}
+
+func (d *webdavBackend) String() string {
+	return redactURL(d.url)
+}
+
+// request returns a request for the file, authorized with the token
+func (d *webdavBackend) request(method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, d.url, body)
+	if err != nil {
+		return nil, err
+	}
+	if user, password, ok := strings.Cut(d.token, ":"); ok {
+		req.SetBasicAuth(user, password)
+	} else if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+	return req, nil
+}
+
+func (d *webdavBackend) pull() (*syncState, error) {
+	req, err := d.request(http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("%s: %s", d, resp.Status)
+	}
+	var state syncState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("%s: %v", d, err)
+	}
+	return &state, nil
+}
+
+func (d *webdavBackend) push(state syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	req, err := d.request(http.MethodPut, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", d, resp.Status)
+	}
+	return nil
+}
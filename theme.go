@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// theme styles the parts of a verse. Each style is a "+"-separated list of
+// bold, dim, italic, a 256-color index such as 208 or a hex color such as
+// #ff9933.
+type theme struct {
+	Header          string
+	Sanskrit        string
+	Transliteration string
+	Translation     string
+	Author          string
+}
+
+// DefaultTheme is the look without -theme: bold headers and dim authors, with
+// the verse blocks styled only by -highlight
+const DefaultTheme = "default"
+
+// themes are the named themes -theme accepts
+var themes = map[string]theme{
+	DefaultTheme: {Header: "bold", Sanskrit: "bold", Transliteration: "dim+italic", Author: "dim"},
+	"mono":       {Header: "bold", Sanskrit: "bold", Transliteration: "italic", Author: "dim"},
+	"saffron": {Header: "bold+#ff9933", Sanskrit: "#ff9933", Transliteration: "italic+#e8b36b",
+		Author: "#b8743d"},
+	"solarized": {Header: "bold+#268bd2", Sanskrit: "#b58900", Transliteration: "italic+#2aa198",
+		Translation: "#93a1a1", Author: "#657b83"},
+}
+
+// themeNames returns the available theme names in sorted order
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// trueColor reports whether the terminal advertises 24-bit color; hex colors
+// are approximated in the 256-color palette otherwise
+func trueColor() bool {
+	c := os.Getenv("COLORTERM")
+	return c == "truecolor" || c == "24bit"
+}
+
+// applyTheme sets the style variables from t
+func applyTheme(t theme) error {
+	styles := []struct {
+		dst  *string
+		spec string
+	}{
+		{&HeaderStyle, t.Header},
+		{&SanskritStyle, t.Sanskrit},
+		{&TransliterationStyle, t.Transliteration},
+		{&TranslationStyle, t.Translation},
+		{&AuthorStyle, t.Author},
+	}
+	for _, s := range styles {
+		style, err := parseStyle(s.spec, trueColor())
+		if err != nil {
+			return err
+		}
+		*s.dst = style
+	}
+	return nil
+}
+
+// parseStyle returns the ANSI escapes of a theme style
+func parseStyle(spec string, truecolor bool) (string, error) {
+	var b strings.Builder
+	for _, part := range strings.Split(spec, "+") {
+		switch part = strings.TrimSpace(part); {
+		case part == "":
+		case part == "bold":
+			b.WriteString(Bold)
+		case part == "dim":
+			b.WriteString(Dim)
+		case part == "italic":
+			b.WriteString(Italic)
+		case strings.HasPrefix(part, "#") && len(part) == 7:
+			rgb, err := strconv.ParseUint(part[1:], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid color %q", part)
+			}
+			red, green, blue := int(rgb>>16), int(rgb>>8&0xff), int(rgb&0xff)
+			if truecolor {
+				fmt.Fprintf(&b, "\033[38;2;%d;%d;%dm", red, green, blue)
+			} else {
+				fmt.Fprintf(&b, "\033[38;5;%dm", color256(red, green, blue))
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 || n > 255 {
+				return "", fmt.Errorf("invalid style %q", part)
+			}
+			fmt.Fprintf(&b, "\033[38;5;%dm", n)
+		}
+	}
+	return b.String(), nil
+}
+
+// color256 returns the nearest color of the 6x6x6 cube of the 256-color palette
+func color256(red, green, blue int) int {
+	level := func(c int) int {
+		if c < 48 {
+			return 0
+		}
+		if c < 115 {
+			return 1
+		}
+		return (c - 35) / 40
+	}
+	return 16 + 36*level(red) + 6*level(green) + level(blue)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// TopicJSON is a topic tag with the number of verses it covers
+type TopicJSON struct {
+	Topic  string `json:"topic"`
+	Verses int    `json:"verses"`
+}
+
+// topicCounts returns every topic with its verse count in book, sorted by name
+func topicCounts(book gita.Scripture) []TopicJSON {
+	var topics []TopicJSON
+	for _, name := range gita.Topics() {
+		slokas, _ := book.Topic(name)
+		topics = append(topics, TopicJSON{Topic: name, Verses: len(slokas)})
+	}
+	return topics
+}
+
+// printTopics writes an aligned table of topics and their verse counts
+func printTopics(w io.Writer, topics []TopicJSON) {
+	width := len("TOPIC")
+	for _, t := range topics {
+		width = max(width, len(t.Topic))
+	}
+	fmt.Fprintf(w, "%s%-*s  %6s%s\n", Bold, width, "TOPIC", "VERSES", Reset)
+	for _, t := range topics {
+		fmt.Fprintf(w, "%-*s  %6d\n", width, t.Topic, t.Verses)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// transliteration schemes accepted by -scheme
+const (
+	SchemeIAST   = "iast"
+	SchemeITRANS = "itrans"
+	SchemeHK     = "hk"
+	SchemeSLP1   = "slp1"
+)
+
+// schemes lists the -scheme values in the column order of the tables below
+var schemes = []string{SchemeIAST, SchemeITRANS, SchemeHK, SchemeSLP1}
+
+// schemeColumn returns the column of scheme in the tables, or -1
+func schemeColumn(scheme string) int {
+	for i, s := range schemes {
+		if s == scheme {
+			return i
+		}
+	}
+	return -1
+}
+
+// devanagariConsonants maps each consonant to its romanization without the
+// inherent vowel, in IAST, ITRANS, Harvard-Kyoto and SLP1
+var devanagariConsonants = map[rune][4]string{
+	'क': {"k", "k", "k", "k"}, 'ख': {"kh", "kh", "kh", "K"}, 'ग': {"g", "g", "g", "g"},
+	'घ': {"gh", "gh", "gh", "G"}, 'ङ': {"ṅ", "~N", "G", "N"},
+	'च': {"c", "ch", "c", "c"}, 'छ': {"ch", "Ch", "ch", "C"}, 'ज': {"j", "j", "j", "j"},
+	'झ': {"jh", "jh", "jh", "J"}, 'ञ': {"ñ", "~n", "J", "Y"},
+	'ट': {"ṭ", "T", "T", "w"}, 'ठ': {"ṭh", "Th", "Th", "W"}, 'ड': {"ḍ", "D", "D", "q"},
+	'ढ': {"ḍh", "Dh", "Dh", "Q"}, 'ण': {"ṇ", "N", "N", "R"},
+	'त': {"t", "t", "t", "t"}, 'थ': {"th", "th", "th", "T"}, 'द': {"d", "d", "d", "d"},
+	'ध': {"dh", "dh", "dh", "D"}, 'न': {"n", "n", "n", "n"},
+	'प': {"p", "p", "p", "p"}, 'फ': {"ph", "ph", "ph", "P"}, 'ब': {"b", "b", "b", "b"},
+	'भ': {"bh", "bh", "bh", "B"}, 'म': {"m", "m", "m", "m"},
+	'य': {"y", "y", "y", "y"}, 'र': {"r", "r", "r", "r"}, 'ल': {"l", "l", "l", "l"},
+	'व': {"v", "v", "v", "v"}, 'ळ': {"ḷ", "L", "L", "L"},
+	'श': {"ś", "sh", "z", "S"}, 'ष': {"ṣ", "Sh", "S", "z"}, 'स': {"s", "s", "s", "s"},
+	'ह': {"h", "h", "h", "h"},
+}
+
+// devanagariVowels maps independent vowels and vowel signs to their romanization
+var devanagariVowels = map[rune][4]string{
+	'अ': {"a", "a", "a", "a"},
+	'आ': {"ā", "A", "A", "A"}, 'ा': {"ā", "A", "A", "A"},
+	'इ': {"i", "i", "i", "i"}, 'ि': {"i", "i", "i", "i"},
+	'ई': {"ī", "I", "I", "I"}, 'ी': {"ī", "I", "I", "I"},
+	'उ': {"u", "u", "u", "u"}, 'ु': {"u", "u", "u", "u"},
+	'ऊ': {"ū", "U", "U", "U"}, 'ू': {"ū", "U", "U", "U"},
+	'ऋ': {"ṛ", "RRi", "R", "f"}, 'ृ': {"ṛ", "RRi", "R", "f"},
+	'ॠ': {"ṝ", "RRI", "RR", "F"}, 'ॄ': {"ṝ", "RRI", "RR", "F"},
+	'ऌ': {"ḷ", "LLi", "lR", "x"}, 'ॢ': {"ḷ", "LLi", "lR", "x"},
+	'ॡ': {"ḹ", "LLI", "lRR", "X"}, 'ॣ': {"ḹ", "LLI", "lRR", "X"},
+	'ए': {"e", "e", "e", "e"}, 'े': {"e", "e", "e", "e"},
+	'ऐ': {"ai", "ai", "ai", "E"}, 'ै': {"ai", "ai", "ai", "E"},
+	'ओ': {"o", "o", "o", "o"}, 'ो': {"o", "o", "o", "o"},
+	'औ': {"au", "au", "au", "O"}, 'ौ': {"au", "au", "au", "O"},
+}
+
+// devanagariMarks maps the other signs, punctuation and digits
+var devanagariMarks = map[rune][4]string{
+	'ं': {"ṃ", "M", "M", "M"}, 'ः': {"ḥ", "H", "H", "H"}, 'ँ': {"m̐", ".N", "~", "~"},
+	'ऽ': {"'", ".a", "'", "'"}, 'ॐ': {"oṃ", "OM", "OM", "oM"},
+	'।': {"|", "|", "|", "."}, '॥': {"||", "||", "||", ".."},
+}
+
+const (
+	virama = '्'
+	nukta  = '़'
+)
+
+// isVowelSign reports whether r is a dependent vowel sign (matra)
+func isVowelSign(r rune) bool {
+	return r >= 'ा' && r <= 'ौ' || r == 'ॢ' || r == 'ॣ'
+}
+
+// transliterate romanizes Devanagari text in scheme, one of schemes. Other
+// characters, such as spaces and Latin punctuation, are kept.
+func transliterate(text, scheme string) (string, error) {
+	col := schemeColumn(scheme)
+	if col < 0 {
+		return "", fmt.Errorf("unknown scheme %q", scheme)
+	}
+	var b strings.Builder
+	pending := false // a consonant waits for its vowel
+	for _, r := range text {
+		if c, ok := devanagariConsonants[r]; ok {
+			if pending {
+				b.WriteString("a")
+			}
+			b.WriteString(c[col])
+			pending = true
+			continue
+		}
+		switch {
+		case r == nukta:
+			continue
+		case r == virama:
+			pending = false
+			continue
+		}
+		if v, ok := devanagariVowels[r]; ok {
+			// a vowel sign replaces the inherent vowel, an independent
+			// vowel follows it
+			if pending && !isVowelSign(r) {
+				b.WriteString("a")
+			}
+			b.WriteString(v[col])
+			pending = false
+			continue
+		}
+		if pending {
+			b.WriteString("a")
+			pending = false
+		}
+		switch m, ok := devanagariMarks[r]; {
+		case ok:
+			b.WriteString(m[col])
+		case r >= '०' && r <= '९':
+			b.WriteRune('0' + r - '०')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if pending {
+		b.WriteString("a")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// defaultDataURL is the curated gita.json attached to the latest release,
+// next to its checksum at the same URL with .sha256 appended
+const defaultDataURL = "https://github.com/ashish0kumar/gitasay/releases/latest/download/gita.json"
+
+const (
+	updateTimeout = time.Minute // for each download
+	maxDataSize   = 64 << 20    // largest dataset accepted
+)
+
+// dataDir returns the directory for downloaded data, honoring XDG_DATA_HOME
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "gitasay"), nil
+	}
+	dir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitasay"), nil
+}
+
+// updatedDataPath returns where update-data keeps the downloaded gita.json
+func updatedDataPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gita.json"), nil
+}
+
+// download returns the body at rawURL
+func download(client *http.Client, rawURL string) ([]byte, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDataSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDataSize {
+		return nil, fmt.Errorf("%s: larger than %d MB", rawURL, maxDataSize>>20)
+	}
+	return data, nil
+}
+
+// updateData downloads the dataset at rawURL, checks it against the SHA-256
+// sum published next to it and against the gita.json schema, and saves it
+// at path. It returns the dataset, and false when path already held it.
+func updateData(rawURL, path string) (*gita.Gita, bool, error) {
+	client := &http.Client{Timeout: updateTimeout}
+	sum, err := download(client, rawURL+".sha256")
+	if err != nil {
+		return nil, false, fmt.Errorf("checksum: %v", err)
+	}
+	// the sum is the first field, as written by sha256sum
+	fields := strings.Fields(string(sum))
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return nil, false, errors.New("checksum: not a SHA-256 sum")
+	}
+	want := strings.ToLower(fields[0])
+	data, err := download(client, rawURL)
+	if err != nil {
+		return nil, false, err
+	}
+	got := sha256.Sum256(data)
+	if hex.EncodeToString(got[:]) != want {
+		return nil, false, fmt.Errorf("checksum mismatch: the download has %x, the release lists %s", got, want)
+	}
+	book, err := gita.Parse(data)
+	if err != nil {
+		return nil, false, err
+	}
+	// refuse a dataset that would show verses wrong, as doctor reports them
+	if report := checkDatasetFile(rawURL, data); report.Errors > 0 {
+		for _, p := range report.Problems {
+			if p.Severity == SeverityError {
+				return nil, false, fmt.Errorf("the dataset has %s, such as %s: %s", count(report.Errors, "error"), p.Where, p.Message)
+			}
+		}
+	}
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return book, false, nil
+	}
+
+	// write next to the file so a failed write leaves the old copy in place
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, false, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, false, err
+	}
+	return book, true, os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashish0kumar/gitasay/gita"
+)
+
+// topChapters is how many of the most viewed chapters the stats list
+const topChapters = 5
+
+// usageLog is the persisted record of the verses shown, for the stats command
+type usageLog struct {
+	Days  []string       `json:"days"`  // dates on which verses were shown, in order
+	Views map[string]int `json:"views"` // times each sloka id was shown
+}
+
+// ChapterViews is how often the verses of a chapter were shown
+type ChapterViews struct {
+	Chapter int    `json:"chapter"`
+	Name    string `json:"name"`
+	Views   int    `json:"views"`
+}
+
+// UsageStats summarizes the usage record
+type UsageStats struct {
+	Streak        int            `json:"streak"`         // days in a row with verses shown, up to today
+	LongestStreak int            `json:"longest_streak"` // longest such run
+	DaysActive    int            `json:"days_active"`
+	Views         int            `json:"views"`         // verses shown, counting repeats
+	UniqueVerses  int            `json:"unique_verses"` // distinct verses shown
+	Verses        int            `json:"verses"`        // verses in the book
+	Coverage      float64        `json:"coverage"`      // percent of the book shown
+	Chapters      []ChapterViews `json:"chapters"`      // most viewed first
+}
+
+// usagePath returns the file holding the usage record
+func usagePath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.json"), nil
+}
+
+// loadUsage returns the usage record. A missing file means nothing has been
+// shown yet.
+func loadUsage() (usageLog, error) {
+	u := usageLog{Views: make(map[string]int)}
+	path, err := usagePath()
+	if err != nil {
+		return u, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return u, err
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return u, fmt.Errorf("%s: %v", path, err)
+	}
+	if u.Views == nil {
+		u.Views = make(map[string]int)
+	}
+	return u, nil
+}
+
+// recordViews adds slokas, shown on the date today, to the usage record
+func recordViews(slokas []Sloka, today string) error {
+	u, err := loadUsage()
+	if err != nil {
+		return err
+	}
+	for _, s := range slokas {
+		u.Views[s.ID]++
+	}
+	if !slices.Contains(u.Days, today) {
+		u.Days = append(u.Days, today)
+		sort.Strings(u.Days)
+	}
+	path, err := usagePath()
+	if err != nil {
+		return err
+	}
+	return writeState(path, u)
+}
+
+// usageStats summarizes u on the date today for book
+func usageStats(u usageLog, book gita.Scripture, today, lang string) UsageStats {
+	slokas := book.Data().Slokas
+	st := UsageStats{DaysActive: len(u.Days), Verses: len(slokas)}
+
+	// a streak is still alive until a whole day goes by without a verse
+	date, _ := time.Parse(time.DateOnly, today)
+	if !slices.Contains(u.Days, today) {
+		date = date.AddDate(0, 0, -1)
+	}
+	for slices.Contains(u.Days, date.Format(time.DateOnly)) {
+		st.Streak++
+		date = date.AddDate(0, 0, -1)
+	}
+	run := 0
+	for i, day := range u.Days {
+		run++
+		if gap, err := daysBetween(u.Days[max(i-1, 0)], day); i > 0 && (err != nil || gap != 1) {
+			run = 1
+		}
+		st.LongestStreak = max(st.LongestStreak, run)
+	}
+
+	views := make(map[int]int)
+	for id, n := range u.Views {
+		st.Views += n
+		if s, ok := book.ByID(id); ok {
+			st.UniqueVerses++
+			views[s.Chapter] += n
+		}
+	}
+	st.Coverage = 100 * float64(st.UniqueVerses) / float64(max(st.Verses, 1))
+	for number, n := range views {
+		cv := ChapterViews{Chapter: number, Views: n}
+		if chapter, ok := book.Chapter(number); ok {
+			cv.Name = chapterName(chapter, lang)
+		}
+		st.Chapters = append(st.Chapters, cv)
+	}
+	sort.Slice(st.Chapters, func(i, j int) bool {
+		if st.Chapters[i].Views != st.Chapters[j].Views {
+			return st.Chapters[i].Views > st.Chapters[j].Views
+		}
+		return st.Chapters[i].Chapter < st.Chapters[j].Chapter
+	})
+	st.Chapters = st.Chapters[:min(len(st.Chapters), topChapters)]
+	return st
+}
+
+// printUsageStats writes the usage summary, the most viewed chapters as an
+// aligned table
+func printUsageStats(w io.Writer, st UsageStats) {
+	fmt.Fprintf(w, "Streak:         %d days (longest %d)\n", st.Streak, st.LongestStreak)
+	fmt.Fprintf(w, "Days active:    %d\n", st.DaysActive)
+	fmt.Fprintf(w, "Verses viewed:  %d\n", st.Views)
+	fmt.Fprintf(w, "Unique verses:  %d of %d (%.0f%%)\n", st.UniqueVerses, st.Verses, st.Coverage)
+	if len(st.Chapters) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nMost viewed chapters:\n")
+	for _, c := range st.Chapters {
+		name := truncate(c.Name, 28)
+		fmt.Fprintf(w, "  %2d  %s%s %5d\n", c.Chapter, name, strings.Repeat(" ", max(0, 28-textWidth(name))), c.Views)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// minWallpaper is the smallest -resolution side, in pixels
+const minWallpaper = 240
+
+// parseResolution parses a WIDTHxHEIGHT resolution such as 2560x1440
+func parseResolution(value string) (int, int, error) {
+	w, h, ok := strings.Cut(strings.ToLower(value), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil {
+		return 0, 0, fmt.Errorf("invalid resolution %q (expected WIDTHxHEIGHT, e.g. 2560x1440)", value)
+	}
+	if width < minWallpaper || height < minWallpaper {
+		return 0, 0, fmt.Errorf("invalid resolution %q (at least %dx%d)", value, minWallpaper, minWallpaper)
+	}
+	return width, height, nil
+}
+
+// imageDataURI returns the picture at path as a data URI, so the wallpaper
+// carries its background with it
+func imageDataURI(path string) (string, error) {
+	kind := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if !strings.HasPrefix(kind, "image/") {
+		return "", fmt.Errorf("unsupported background %q (expected a .png, .jpg or .svg picture)", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + kind + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// writeWallpaper writes s centered on a width by height SVG wallpaper, over
+// the picture at image when given, dimmed with the background color so the
+// text stays readable. The text is sized to the height and wrapped to the
+// middle three fifths of the width.
+func (r renderer) writeWallpaper(w io.Writer, s Sloka, width, height int, image, background, foreground string) error {
+	lines := r.cardLines(s, width*3/5, float64(height)/1080*1.2)
+	block := 0
+	for _, line := range lines {
+		block += lineHeight(line)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", background)
+	if image != "" {
+		uri, err := imageDataURI(image)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "<image href=\"%s\" width=\"100%%\" height=\"100%%\" preserveAspectRatio=\"xMidYMid slice\"/>\n", uri)
+		fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\" opacity=\"0.6\"/>\n", background)
+	}
+	fmt.Fprintf(&b, "<g fill=\"%s\" font-family=\"%s\" text-anchor=\"middle\">\n", foreground, cardFonts)
+	y := max(0, (height-block)/2)
+	for _, line := range lines {
+		y += lineHeight(line)
+		if line.text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\" font-size=\"%d\"%s>%s</text>\n",
+			width/2, y, line.size, line.style, html.EscapeString(line.text))
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeWallpaperFile writes the wallpaper of s to path as SVG, or as PNG for
+// a .png path
+func (r renderer) writeWallpaperFile(path string, s Sloka, width, height int, image, background, foreground string) error {
+	var svg strings.Builder
+	if err := r.writeWallpaper(&svg, s, width, height, image, background, foreground); err != nil {
+		return err
+	}
+	return writeImage(path, svg.String())
+}
+
+// errNoSetter is returned when no way to set the desktop wallpaper is found
+var errNoSetter = errors.New("no way to set the wallpaper found")
+
+// setWallpaper makes the picture at path the desktop wallpaper: through
+// System Events on macOS and, on Linux, gsettings on GNOME and its relatives
+// or feh on other window managers
+func setWallpaper(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	run := func(name string, args ...string) error {
+		cmd := exec.Command(name, args...)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return run("osascript",
+			"-e", "on run argv",
+			"-e", `tell application "System Events" to tell every desktop to set picture to (item 1 of argv)`,
+			"-e", "end run", path)
+	case "linux":
+		desktop := strings.ToLower(os.Getenv("XDG_CURRENT_DESKTOP"))
+		_, gsettingsErr := exec.LookPath("gsettings")
+		_, fehErr := exec.LookPath("feh")
+		gnome := strings.Contains(desktop, "gnome") || strings.Contains(desktop, "unity") ||
+			strings.Contains(desktop, "budgie") || strings.Contains(desktop, "pantheon")
+		switch {
+		case gsettingsErr == nil && (gnome || fehErr != nil):
+			uri := (&url.URL{Scheme: "file", Path: path}).String()
+			// GNOME ignores a URI it already shows, though the picture changed
+			run("gsettings", "set", "org.gnome.desktop.background", "picture-uri", "")
+			if err := run("gsettings", "set", "org.gnome.desktop.background", "picture-uri", uri); err != nil {
+				return err
+			}
+			// the dark style has its own wallpaper from GNOME 42, unknown before
+			exec.Command("gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", uri).Run()
+			return nil
+		case fehErr == nil:
+			return run("feh", "--bg-fill", path)
+		}
+	}
+	return errNoSetter
+}
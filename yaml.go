@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// writeYAML writes v as a YAML document. It covers the types used for -json
+// output: structs (using their json tags and omitempty), pointers, maps with
+// string keys, slices, strings and numbers. Field order follows the struct.
+func writeYAML(w io.Writer, v any) error {
+	var b strings.Builder
+	yamlValue(&b, reflect.ValueOf(v), 0, false)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// yamlValue writes rv at the given indentation. inline is set when rv
+// follows a "key:" or "- " on the current line.
+func yamlValue(b *strings.Builder, rv reflect.Value, indent int, inline bool) {
+	pad := strings.Repeat("  ", indent)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			b.WriteString(" null\n")
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		if inline {
+			b.WriteString("\n")
+		}
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, omitEmpty := jsonName(t.Field(i))
+			if name == "" || (omitEmpty && rv.Field(i).IsZero()) {
+				continue
+			}
+			fmt.Fprintf(b, "%s%s:", pad, name)
+			yamlValue(b, rv.Field(i), indent+1, true)
+		}
+	case reflect.Map:
+		if inline {
+			b.WriteString("\n")
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			fmt.Fprintf(b, "%s%s:", pad, k.String())
+			yamlValue(b, rv.MapIndex(k), indent+1, true)
+		}
+	case reflect.Slice:
+		if rv.Len() == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		if inline {
+			b.WriteString("\n")
+		}
+		for i := 0; i < rv.Len(); i++ {
+			// items are written as "- " followed by their fields
+			var item strings.Builder
+			yamlValue(&item, rv.Index(i), indent+1, false)
+			text := strings.TrimPrefix(item.String(), pad+"  ")
+			fmt.Fprintf(b, "%s- %s", pad, strings.TrimPrefix(text, " "))
+		}
+	case reflect.String:
+		b.WriteString(" " + yamlString(rv.String(), pad) + "\n")
+	default:
+		fmt.Fprintf(b, " %v\n", rv.Interface())
+	}
+}
+
+// jsonName returns the key of f from its json tag, "" for skipped fields
+func jsonName(f reflect.StructField) (name string, omitEmpty bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+// yamlString formats s as a YAML scalar: a literal block for multi-line
+// text, otherwise a double-quoted string, which YAML shares with JSON
+func yamlString(s, pad string) string {
+	if strings.Contains(s, "\n") && !strings.ContainsAny(s, "\r\t") && !strings.HasPrefix(s, " ") {
+		lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+		return "|-\n" + pad + "  " + strings.Join(lines, "\n"+pad+"  ")
+	}
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}